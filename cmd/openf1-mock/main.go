@@ -0,0 +1,168 @@
+// Command openf1-mock sirve fixtures JSON grabados de OpenF1 para poder
+// poblar proxy.db sin acceso a internet.
+//
+// Cada endpoint /v1/<nombre> se resuelve con el archivo <nombre>.json del
+// directorio de fixtures (por defecto los embebidos en el binario) y se
+// filtra con los parámetros de la query igual que la API real, por ejemplo
+// /v1/position?session_key=9574. También se aceptan los operadores de
+// comparación de OpenF1 (date_start>=..., date<...).
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//go:embed fixtures/*.json
+var embeddedFixtures embed.FS
+
+type filter struct {
+	field string
+	op    string
+	value string
+}
+
+func main() {
+	addr := flag.String("addr", ":8081", "dirección en la que escuchar")
+	dir := flag.String("fixtures", "", "directorio con fixtures JSON (por defecto los embebidos)")
+	flag.Parse()
+
+	var fixtures fs.FS
+	if *dir != "" {
+		fixtures = os.DirFS(*dir)
+	} else {
+		sub, err := fs.Sub(embeddedFixtures, "fixtures")
+		if err != nil {
+			log.Fatal("Error cargando fixtures embebidos:", err)
+		}
+		fixtures = sub
+	}
+
+	http.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+		if endpoint == "" || strings.Contains(endpoint, "/") {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not Found"})
+			return
+		}
+
+		data, err := fs.ReadFile(fixtures, endpoint+".json")
+		if err != nil {
+			log.Printf("❌ Sin fixture para %s", endpoint)
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not Found"})
+			return
+		}
+
+		var records []map[string]interface{}
+		if err := json.Unmarshal(data, &records); err != nil {
+			log.Printf("❌ Fixture %s.json inválido: %v", endpoint, err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+			return
+		}
+
+		filters := parseFilters(r.URL.RawQuery)
+		result := make([]map[string]interface{}, 0)
+		for _, rec := range records {
+			if matches(rec, filters) {
+				result = append(result, rec)
+			}
+		}
+
+		log.Printf("📡 %s -> %d registros", r.URL.RequestURI(), len(result))
+		if len(result) == 0 {
+			// OpenF1 responde 404 cuando no hay resultados
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "No results found."})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	log.Printf("🏁 Mock de OpenF1 escuchando en %s (base URL: http://localhost%s/v1)", *addr, *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatal("No se pudo iniciar el mock:", err)
+	}
+}
+
+// parseFilters interpreta la query a mano porque url.ParseQuery separa
+// "date>=X" en la clave "date>" y no entiende "date>X".
+func parseFilters(rawQuery string) []filter {
+	var filters []filter
+	for _, part := range strings.Split(rawQuery, "&") {
+		part, err := url.QueryUnescape(part)
+		if err != nil || part == "" {
+			continue
+		}
+		for _, op := range []string{">=", "<=", ">", "<", "="} {
+			if i := strings.Index(part, op); i > 0 {
+				filters = append(filters, filter{field: part[:i], op: op, value: part[i+len(op):]})
+				break
+			}
+		}
+	}
+	return filters
+}
+
+func matches(rec map[string]interface{}, filters []filter) bool {
+	for _, f := range filters {
+		v, ok := rec[f.field]
+		if !ok || v == nil {
+			return false
+		}
+		if !compare(v, f.op, f.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func compare(v interface{}, op, want string) bool {
+	var cmp int
+	switch val := v.(type) {
+	case float64:
+		w, err := strconv.ParseFloat(want, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case val < w:
+			cmp = -1
+		case val > w:
+			cmp = 1
+		}
+	case bool:
+		cmp = strings.Compare(strconv.FormatBool(val), strings.ToLower(want))
+	default:
+		// Las fechas ISO 8601 se comparan bien como texto
+		cmp = strings.Compare(fmt.Sprint(val), want)
+	}
+
+	switch op {
+	case "=":
+		return cmp == 0
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ Error escribiendo respuesta: %v", err)
+	}
+}
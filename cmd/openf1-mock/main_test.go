@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []filter
+	}{
+		{"", nil},
+		{"session_key=9574", []filter{{"session_key", "=", "9574"}}},
+		{
+			"session_key=9574&driver_number=1",
+			[]filter{{"session_key", "=", "9574"}, {"driver_number", "=", "1"}},
+		},
+		{
+			// Las comparaciones llegan como parte de la clave, a veces escapadas
+			"date>=2024-07-28T13:00:00&date%3C2024-07-28T14:00:00",
+			[]filter{{"date", ">=", "2024-07-28T13:00:00"}, {"date", "<", "2024-07-28T14:00:00"}},
+		},
+		{"lap_number<=3&lap_number>1", []filter{{"lap_number", "<=", "3"}, {"lap_number", ">", "1"}}},
+		{"session_name=Race&&year=2024", []filter{{"session_name", "=", "Race"}, {"year", "=", "2024"}}},
+		// Sin campo o con escape inválido se ignora
+		{"=9574&%zz=1&speed>=300", []filter{{"speed", ">=", "300"}}},
+	}
+	for _, tt := range tests {
+		if got := parseFilters(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFilters(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	rec := map[string]interface{}{
+		"session_key":    float64(9574),
+		"session_name":   "Race",
+		"date":           "2024-07-28T13:05:00.120000+00:00",
+		"is_pit_out_lap": false,
+		"lap_duration":   nil,
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"session_key=9574", true},
+		{"session_key=9573", false},
+		{"session_key>=9574&session_key<9575", true},
+		{"session_key>9574", false},
+		{"session_name=Race", true},
+		{"session_name=Sprint", false},
+		{"date>=2024-07-28T13:00:00", true},
+		{"date<2024-07-28T13:05:00", false},
+		{"is_pit_out_lap=false", true},
+		{"is_pit_out_lap=FALSE", true},
+		{"is_pit_out_lap=true", false},
+		// Campos sin valor o inexistentes no coinciden con nada
+		{"lap_duration>0", false},
+		{"driver_number=1", false},
+		// Un número que no se puede parsear no coincide
+		{"session_key=abc", false},
+	}
+	for _, tt := range tests {
+		if got := matches(rec, parseFilters(tt.query)); got != tt.want {
+			t.Errorf("matches(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Estados de un piloto en la clasificación final
+const (
+	classificationFinished     = "finished"
+	classificationDNF          = "dnf"
+	classificationDNS          = "dns"
+	classificationDisqualified = "dsq"
+)
+
+// Orden en la tabla: clasificados, abandonos, no largaron y descalificados
+var classificationGroup = map[string]int{
+	classificationFinished:     0,
+	classificationDNF:          1,
+	classificationDNS:          2,
+	classificationDisqualified: 3,
+}
+
+// Classification es el resultado final de cada piloto en una sesión. Se
+// calcula una vez al ingerir la sesión a partir de sus posiciones y vueltas,
+// así los handlers no reconstruyen la clasificación en cada consulta.
+type Classification struct {
+	SessionKey    int     `json:"session_key" gorm:"primaryKey;autoIncrement:false"`
+	DriverNumber  uint    `json:"driver_number" gorm:"primaryKey;autoIncrement:false"`
+	Position      int     `json:"position"`
+	Status        string  `json:"status"`
+	Classified    bool    `json:"classified"`
+	LapsCompleted int     `json:"laps_completed"`
+	TotalTime     float64 `json:"total_time"`
+	GapToWinner   float64 `json:"gap_to_winner"`
+	LapsBehind    int     `json:"laps_behind"`
+	GridPosition  int     `json:"grid_position"` // primera muestra de posición
+
+	// Faltan duraciones de vuelta que no se pueden deducir: el tiempo total
+	// es una cota inferior
+	TimeIncomplete bool `json:"time_incomplete"`
+}
+
+// driverTotals son las vueltas completadas y el tiempo total de un piloto
+type driverTotals struct {
+	Laps       int
+	Total      float64
+	Incomplete bool
+}
+
+// lapTotals calcula las vueltas y el tiempo total de cada piloto de una
+// sesión. El tiempo va desde la largada (el primer inicio de vuelta 1, igual
+// para todos) hasta el final de la última vuelta con hora de inicio, más la
+// duración de las vueltas siguientes; así no importan las vueltas
+// intermedias sin duración, como la primera, que OpenF1 publica sin ella.
+// Sin horas de inicio se suman las duraciones conocidas.
+func lapTotals(tx *gorm.DB, sessionKey int) (map[uint]driverTotals, error) {
+	var laps []Lap
+	err := tx.Select("driver_number, lap_number, date_start, lap_duration").
+		Where("session_key = ?", sessionKey).
+		Order("driver_number ASC, lap_number ASC").
+		Find(&laps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var raceStart time.Time
+	for _, l := range laps {
+		if t, ok := parseOpenF1Time(l.DateStart); ok && l.LapNumber == 1 && (raceStart.IsZero() || t.Before(raceStart)) {
+			raceStart = t
+		}
+	}
+
+	// Por piloto: el último instante conocido (end), lo corrido después de él
+	// y si a eso le falta alguna duración
+	type driverLaps struct {
+		driverTotals
+		sum      float64
+		missing  bool
+		end      time.Time
+		after    float64
+		afterGap bool
+	}
+	acc := make(map[uint]*driverLaps)
+	for _, l := range laps {
+		d := acc[l.DriverNumber]
+		if d == nil {
+			d = &driverLaps{}
+			acc[l.DriverNumber] = d
+		}
+		if l.LapNumber > d.Laps {
+			d.Laps = l.LapNumber
+		}
+		if l.LapDuration > 0 {
+			d.sum += l.LapDuration
+		} else {
+			d.missing = true
+		}
+
+		start, hasStart := parseOpenF1Time(l.DateStart)
+		switch {
+		case hasStart && l.LapDuration > 0:
+			d.end = start.Add(time.Duration(l.LapDuration * float64(time.Second)))
+			d.after, d.afterGap = 0, false
+		case hasStart:
+			// Se sabe cuándo empezó, no cuándo terminó
+			d.end = start
+			d.after, d.afterGap = 0, true
+		case l.LapDuration > 0:
+			d.after += l.LapDuration
+		default:
+			d.afterGap = true
+		}
+	}
+
+	out := make(map[uint]driverTotals, len(acc))
+	for dn, d := range acc {
+		t := d.driverTotals
+		if raceStart.IsZero() || !d.end.After(raceStart) {
+			t.Total = d.sum
+			t.Incomplete = d.missing
+		} else {
+			t.Total = d.end.Sub(raceStart).Seconds() + d.after
+			t.Incomplete = d.afterGap
+		}
+		out[dn] = t
+	}
+	return out, nil
+}
+
+// buildClassification recalcula la clasificación de una sesión con las
+// posiciones, vueltas y mensajes de dirección de carrera ya guardados en
+// tx. El orden base es la última muestra de posición de cada piloto; en
+// carreras y sprints además se detectan abandonos, pilotos que no largaron
+// y descalificados, y se calcula el tiempo total y la diferencia con el
+// ganador.
+func buildClassification(tx *gorm.DB, s Session) error {
+	var positions []Position
+	err := tx.Raw(`
+        SELECT p.*
+        FROM positions p
+        JOIN (
+            SELECT driver_number, MAX(date) AS date
+            FROM positions
+            WHERE session_key = ?
+            GROUP BY driver_number
+        ) last ON last.driver_number = p.driver_number AND last.date = p.date
+        WHERE p.session_key = ?
+        ORDER BY p.position ASC
+    `, s.SessionKey, s.SessionKey).Scan(&positions).Error
+	if err != nil {
+		return err
+	}
+
+	byDriver, err := lapTotals(tx, s.SessionKey)
+	if err != nil {
+		return err
+	}
+	grid, err := startingPositions(tx, s.SessionKey)
+	if err != nil {
+		return err
+	}
+
+	isRace := s.SessionName == sessionRace || s.SessionName == sessionSprint
+	if !isRace {
+		rows := make([]Classification, 0, len(positions))
+		for _, p := range positions {
+			rows = append(rows, Classification{
+				SessionKey:    s.SessionKey,
+				DriverNumber:  p.DriverNumber,
+				Position:      p.Position,
+				Status:        classificationFinished,
+				Classified:    true,
+				LapsCompleted: byDriver[p.DriverNumber].Laps,
+				GridPosition:  grid[p.DriverNumber],
+			})
+		}
+		return replaceClassification(tx, s.SessionKey, rows)
+	}
+
+	// Estado anunciado por dirección de carrera para cada piloto
+	var messages []RaceControl
+	err = tx.Where("session_key = ? AND driver_number > 0", s.SessionKey).Find(&messages).Error
+	if err != nil {
+		return err
+	}
+	announced := make(map[uint]string)
+	for _, m := range messages {
+		status := statusFromMessage(m.Message)
+		if status == "" {
+			continue
+		}
+		if prev, ok := announced[m.DriverNumber]; !ok || classificationGroup[status] > classificationGroup[prev] {
+			announced[m.DriverNumber] = status
+		}
+	}
+
+	// Vueltas del líder: las de quien más completó sin ser descalificado
+	leaderLaps := 0
+	for _, p := range positions {
+		if announced[p.DriverNumber] != classificationDisqualified && byDriver[p.DriverNumber].Laps > leaderLaps {
+			leaderLaps = byDriver[p.DriverNumber].Laps
+		}
+	}
+
+	rows := make([]Classification, 0, len(positions))
+	for _, p := range positions {
+		t := byDriver[p.DriverNumber]
+		row := Classification{
+			SessionKey:     s.SessionKey,
+			DriverNumber:   p.DriverNumber,
+			Position:       p.Position,
+			LapsCompleted:  t.Laps,
+			GridPosition:   grid[p.DriverNumber],
+			TotalTime:      roundMillis(t.Total),
+			TimeIncomplete: t.Incomplete,
+		}
+
+		// Se clasifica quien cubre al menos el 90% de las vueltas del líder,
+		// aunque haya abandonado
+		coveredDistance := t.Laps*10 >= leaderLaps*9
+		switch {
+		case announced[p.DriverNumber] == classificationDisqualified:
+			row.Status = classificationDisqualified
+		case announced[p.DriverNumber] == classificationDNS || t.Laps == 0:
+			row.Status = classificationDNS
+		case t.Laps < leaderLaps && (announced[p.DriverNumber] == classificationDNF || !coveredDistance):
+			row.Status = classificationDNF
+			row.Classified = coveredDistance
+		default:
+			row.Status = classificationFinished
+			row.Classified = true
+		}
+		rows = append(rows, row)
+	}
+
+	// Los clasificados van primero (más vueltas adelante, respetando el orden
+	// de la última muestra), luego abandonos, no largaron y descalificados
+	sort.SliceStable(rows, func(i, j int) bool {
+		gi, gj := classificationGroup[rows[i].Status], classificationGroup[rows[j].Status]
+		if rows[i].Classified {
+			gi = -1
+		}
+		if rows[j].Classified {
+			gj = -1
+		}
+		if gi != gj {
+			return gi < gj
+		}
+		return rows[i].LapsCompleted > rows[j].LapsCompleted
+	})
+	for i := range rows {
+		rows[i].Position = i + 1
+	}
+
+	// Diferencia con el ganador: en tiempo si completó las mismas vueltas,
+	// en vueltas si fue doblado o abandonó
+	if len(rows) > 0 && rows[0].Classified {
+		winner := rows[0]
+		for i := range rows {
+			switch {
+			case rows[i].Status == classificationDNS || rows[i].Status == classificationDisqualified:
+				continue
+			case rows[i].LapsCompleted >= winner.LapsCompleted:
+				rows[i].GapToWinner = roundMillis(rows[i].TotalTime - winner.TotalTime)
+			default:
+				rows[i].LapsBehind = winner.LapsCompleted - rows[i].LapsCompleted
+			}
+		}
+		if err := applyIntervalGaps(tx, s.SessionKey, rows); err != nil {
+			return err
+		}
+	}
+
+	return replaceClassification(tx, s.SessionKey, rows)
+}
+
+// applyIntervalGaps corrige la diferencia con el ganador (rows[0]) de los
+// pilotos que terminaron con su última muestra de intervalos, la que OpenF1
+// toma en la meta y que es más precisa que la suma de vueltas. Su tiempo
+// total pasa a ser el del ganador más esa diferencia, así la clasificación
+// y el detalle de la carrera dan el mismo número. Sin intervalos queda el
+// cálculo por vueltas.
+func applyIntervalGaps(tx *gorm.DB, sessionKey int, rows []Classification) error {
+	var last []Interval
+	err := tx.Raw(`
+        SELECT i.*
+        FROM intervals i
+        JOIN (
+            SELECT driver_number, MAX(date) AS date
+            FROM intervals
+            WHERE session_key = ?
+            GROUP BY driver_number
+        ) last ON last.driver_number = i.driver_number AND last.date = i.date
+        WHERE i.session_key = ?
+    `, sessionKey, sessionKey).Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	lastBy := make(map[uint]Interval, len(last))
+	for _, iv := range last {
+		lastBy[iv.DriverNumber] = iv
+	}
+
+	// El ganador puede no ser el líder de los intervalos (por ejemplo, si el
+	// primero en cruzar la meta fue descalificado)
+	winner, ok := lastBy[rows[0].DriverNumber]
+	if !ok {
+		return nil
+	}
+	for i := range rows {
+		iv, ok := lastBy[rows[i].DriverNumber]
+		if !ok || rows[i].Status != classificationFinished {
+			continue
+		}
+		if lapsBehind := iv.LapsToLeader - winner.LapsToLeader; lapsBehind > 0 {
+			rows[i].GapToWinner = 0
+			rows[i].LapsBehind = lapsBehind
+			continue
+		}
+		rows[i].GapToWinner = roundMillis(iv.GapToLeader - winner.GapToLeader)
+		rows[i].LapsBehind = 0
+		rows[i].TotalTime = roundMillis(rows[0].TotalTime + rows[i].GapToWinner)
+		rows[i].TimeIncomplete = rows[0].TimeIncomplete
+	}
+	return nil
+}
+
+// statusFromMessage reconoce los mensajes de dirección de carrera que
+// cambian el estado final de un piloto
+func statusFromMessage(message string) string {
+	message = strings.ToUpper(message)
+	switch {
+	case strings.Contains(message, "DISQUALIFIED"):
+		return classificationDisqualified
+	case strings.Contains(message, "DID NOT START"):
+		return classificationDNS
+	case strings.Contains(message, "RETIRED"), strings.Contains(message, "STOPPED"):
+		return classificationDNF
+	}
+	return ""
+}
+
+// replaceClassification reemplaza la clasificación guardada de una sesión
+func replaceClassification(tx *gorm.DB, sessionKey int, rows []Classification) error {
+	if err := tx.Where("session_key = ?", sessionKey).Delete(&Classification{}).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(rows, 1000).Error
+}
+
+// roundMillis redondea un tiempo en segundos a milésimas, la precisión de
+// los tiempos de vuelta de OpenF1
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+// sessionClassification devuelve la clasificación final de una sesión
+// ordenada por posición
+func sessionClassification(sessionKey int) ([]Classification, error) {
+	var rows []Classification
+	err := db.Where("session_key = ?", sessionKey).Order("position ASC").Find(&rows).Error
+	return rows, err
+}
+
+// GET /api/carrera/clasificacion/:id
+func getSessionClassification(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
+		return
+	}
+
+	// Mejor vuelta y paradas en boxes de cada piloto, en una consulta cada una
+	pitStopsBy := pitStopCounts(sessionKey)
+	type driverStat struct {
+		DriverNumber uint
+		Value        float64
+	}
+	var bestLaps []driverStat
+	db.Model(&Lap{}).
+		Select("driver_number, MIN(lap_duration) AS value").
+		Where("session_key = ? AND lap_duration > 0", sessionKey).
+		Group("driver_number").
+		Scan(&bestLaps)
+	bestLapBy := make(map[uint]float64, len(bestLaps))
+	for _, b := range bestLaps {
+		bestLapBy[b.DriverNumber] = b.Value
+	}
+
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	out := make([]gin.H, 0, len(rows))
+	for i, r := range rows {
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+
+		// Diferencia con el de adelante, solo si están en la misma vuelta
+		var interval interface{}
+		if i > 0 && r.Classified && rows[i-1].Classified && r.LapsBehind == 0 && rows[i-1].LapsBehind == 0 {
+			interval = roundMillis(r.GapToWinner - rows[i-1].GapToWinner)
+		}
+
+		out = append(out, gin.H{
+			"position":        r.Position,
+			"driver_number":   r.DriverNumber,
+			"driver":          fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":            team,
+			"team_colour":     teamColour,
+			"status":          r.Status,
+			"classified":      r.Classified,
+			"laps":            r.LapsCompleted,
+			"total_time":      r.TotalTime,
+			"time_incomplete": r.TimeIncomplete,
+			"gap_to_leader":   r.GapToWinner,
+			"laps_behind":     r.LapsBehind,
+			"interval":        interval,
+			"best_lap":        bestLapBy[r.DriverNumber],
+			"pit_stops":       pitStopsBy[r.DriverNumber],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"session_name":       session.SessionName,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"date_start":         session.DateStart,
+		"year":               session.Year,
+		"classification":     out,
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestBuildClassification(t *testing.T) {
+	useTestDB(t)
+
+	const key = 9574
+	race := Session{SessionKey: key, SessionName: sessionRace, Year: 2024}
+
+	// Última muestra de posición: el descalificado cruzó primero
+	order := []uint{2, 1, 7, 3, 6, 4, 5}
+	var positions []Position
+	for i, dn := range order {
+		positions = append(positions,
+			Position{SessionKey: key, DriverNumber: dn, Position: i + 1, Date: openF1Time(-60)},
+			Position{SessionKey: key, DriverNumber: dn, Position: i + 1, Date: openF1Time(1000)},
+		)
+	}
+
+	var laps []Lap
+	laps = append(laps, raceLaps(key, 1, 10, 0, 92, 90)...)
+	laps = append(laps, raceLaps(key, 2, 10, 0.2, 91, 90)...)
+	// Vuelta 5 sin duración: el total igual sale del final de la vuelta 10
+	laps = append(laps, raceLaps(key, 7, 10, 0.4, 93, 90, 5)...)
+	laps = append(laps, raceLaps(key, 3, 9, 0.6, 94, 91)...)
+	laps = append(laps, raceLaps(key, 6, 9, 0.8, 95, 91)...)
+	laps = append(laps, raceLaps(key, 4, 5, 1.0, 96, 92)...)
+
+	messages := []RaceControl{
+		{SessionKey: key, Date: openF1Time(1100), Message: "CAR 2 (VER) DISQUALIFIED", DriverNumber: 2},
+		{SessionKey: key, Date: openF1Time(850), Message: "CAR 6 (HUL) RETIRED", DriverNumber: 6},
+	}
+
+	for _, rows := range []interface{}{positions, laps, messages} {
+		if err := db.Create(rows).Error; err != nil {
+			t.Fatalf("insertando datos: %v", err)
+		}
+	}
+
+	if err := buildClassification(db, race); err != nil {
+		t.Fatalf("buildClassification: %v", err)
+	}
+	got, err := sessionClassification(key)
+	if err != nil {
+		t.Fatalf("sessionClassification: %v", err)
+	}
+
+	want := []Classification{
+		// La vuelta 1 cuenta desde la largada: 92 s + 9 vueltas de 90 s
+		{DriverNumber: 1, Position: 1, Status: classificationFinished, Classified: true, LapsCompleted: 10, TotalTime: 902, GridPosition: 2},
+		{DriverNumber: 7, Position: 2, Status: classificationFinished, Classified: true, LapsCompleted: 10, TotalTime: 903, GapToWinner: 1, GridPosition: 3},
+		// 9 de 10 vueltas: cubre el 90% y se clasifica doblado
+		{DriverNumber: 3, Position: 3, Status: classificationFinished, Classified: true, LapsCompleted: 9, TotalTime: 822, LapsBehind: 1, GridPosition: 4},
+		// Abandonó, pero después del 90%
+		{DriverNumber: 6, Position: 4, Status: classificationDNF, Classified: true, LapsCompleted: 9, TotalTime: 823, LapsBehind: 1, GridPosition: 5},
+		{DriverNumber: 4, Position: 5, Status: classificationDNF, LapsCompleted: 5, TotalTime: 464, LapsBehind: 5, GridPosition: 6},
+		{DriverNumber: 5, Position: 6, Status: classificationDNS, GridPosition: 7},
+		{DriverNumber: 2, Position: 7, Status: classificationDisqualified, LapsCompleted: 10, TotalTime: 901, GridPosition: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d filas, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		want[i].SessionKey = key
+		if got[i] != want[i] {
+			t.Errorf("posición %d:\n got  %+v\n want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildClassificationQualifying(t *testing.T) {
+	useTestDB(t)
+
+	const key = 9570
+	quali := Session{SessionKey: key, SessionName: sessionQualifying, Year: 2024}
+
+	positions := []Position{
+		{SessionKey: key, DriverNumber: 16, Position: 2, Date: openF1Time(0)},
+		{SessionKey: key, DriverNumber: 16, Position: 1, Date: openF1Time(100)},
+		{SessionKey: key, DriverNumber: 1, Position: 1, Date: openF1Time(0)},
+		{SessionKey: key, DriverNumber: 1, Position: 2, Date: openF1Time(100)},
+	}
+	if err := db.Create(positions).Error; err != nil {
+		t.Fatalf("insertando posiciones: %v", err)
+	}
+
+	if err := buildClassification(db, quali); err != nil {
+		t.Fatalf("buildClassification: %v", err)
+	}
+	got, err := sessionClassification(key)
+	if err != nil {
+		t.Fatalf("sessionClassification: %v", err)
+	}
+
+	// Fuera de carreras y sprints no hay abandonos: vale la última muestra
+	if len(got) != 2 || got[0].DriverNumber != 16 || got[1].DriverNumber != 1 {
+		t.Fatalf("orden inesperado: %+v", got)
+	}
+	for _, r := range got {
+		if r.Status != classificationFinished || !r.Classified {
+			t.Errorf("piloto %d: status %q classified %v", r.DriverNumber, r.Status, r.Classified)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultOpenF1BaseURL = "https://api.openf1.org/v1"
+
+// Config agrupa los parámetros configurables del servidor.
+// Se resuelve en este orden (el último gana): valores por defecto,
+// archivo de configuración JSON, variables de entorno y flags.
+type Config struct {
+	OpenF1BaseURL string `json:"openf1_base_url"`
+	Source        string `json:"source"`     // openf1, dir o memory
+	SourceDir     string `json:"source_dir"` // volcados JSON para dir/memory
+	Years         []int  `json:"years"`      // temporadas a ingerir
+
+	// Cada cuánto buscar carreras nuevas en segundo plano (0 = desactivado)
+	SyncInterval Duration `json:"sync_interval"`
+
+	// Telemetría (car_data): sesiones a ingerir (vacío = desactivada),
+	// pilotos (vacío = todos) y muestras por segundo que se guardan
+	TelemetrySessions []int   `json:"telemetry_sessions"`
+	TelemetryDrivers  []int   `json:"telemetry_drivers"`
+	TelemetryRate     float64 `json:"telemetry_rate"`
+
+	// Token que exige la API de administración (Authorization: Bearer).
+	// Sin token solo se aceptan pedidos desde la misma máquina.
+	AdminToken string `json:"admin_token"`
+
+	// Solo por flag: sincronizar y salir sin levantar la API
+	SyncOnly bool `json:"-"`
+}
+
+// Configuración global
+var cfg = Config{
+	OpenF1BaseURL: defaultOpenF1BaseURL,
+	Source:        sourceOpenF1,
+	Years:         []int{2024},
+	SyncInterval:  Duration{6 * time.Hour},
+	TelemetryRate: 2,
+}
+
+// Duration permite escribir duraciones como "6h" o "30m" en el JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duración inválida %s: %w", b, err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("duración inválida %q: %w", s, err)
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func loadConfig() error {
+	configPath := flag.String("config", os.Getenv("STATSHUB_CONFIG"), "ruta a un archivo de configuración JSON")
+	baseURL := flag.String("openf1-url", "", "URL base de la API de OpenF1 (por defecto "+defaultOpenF1BaseURL+")")
+	src := flag.String("source", "", "fuente de datos: openf1, dir o memory")
+	srcDir := flag.String("source-dir", "", "directorio con volcados JSON (<endpoint>.json) para las fuentes dir y memory")
+	years := flag.String("years", "", "temporadas a ingerir separadas por coma, por ejemplo 2023,2024,2025")
+	syncInterval := flag.Duration("sync-interval", 0, "cada cuánto buscar carreras nuevas en segundo plano, por ejemplo 30m (0 desactiva)")
+	telemetrySessions := flag.String("telemetry-sessions", "", "sesiones cuya telemetría se ingiere, separadas por coma (por ejemplo 9574,9636)")
+	telemetryDrivers := flag.String("telemetry-drivers", "", "pilotos cuya telemetría se ingiere, separados por coma (por defecto todos)")
+	telemetryRate := flag.Float64("telemetry-rate", 0, "muestras de telemetría por segundo que se guardan (por defecto 2)")
+	adminToken := flag.String("admin-token", "", "token requerido por la API de administración (sin token solo se acepta localhost)")
+	flag.BoolVar(&cfg.SyncOnly, "sync-only", false, "sincronizar las sesiones pendientes o fallidas y salir")
+	flag.Parse()
+
+	// 1. Archivo de configuración
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("error leyendo archivo de configuración %s: %w", *configPath, err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("error parseando archivo de configuración %s: %w", *configPath, err)
+		}
+	}
+
+	// 2. Variables de entorno
+	if v := os.Getenv("OPENF1_BASE_URL"); v != "" {
+		cfg.OpenF1BaseURL = v
+	}
+	if v := os.Getenv("STATSHUB_SOURCE"); v != "" {
+		cfg.Source = v
+	}
+	if v := os.Getenv("STATSHUB_SOURCE_DIR"); v != "" {
+		cfg.SourceDir = v
+	}
+
+	if v := os.Getenv("STATSHUB_YEARS"); v != "" {
+		parsed, err := parseYears(v)
+		if err != nil {
+			return err
+		}
+		cfg.Years = parsed
+	}
+
+	if v := os.Getenv("STATSHUB_SYNC_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("STATSHUB_SYNC_INTERVAL inválido %q: %w", v, err)
+		}
+		cfg.SyncInterval = Duration{d}
+	}
+
+	if v := os.Getenv("STATSHUB_TELEMETRY_SESSIONS"); v != "" {
+		parsed, err := parseNumbers(v)
+		if err != nil {
+			return err
+		}
+		cfg.TelemetrySessions = parsed
+	}
+	if v := os.Getenv("STATSHUB_TELEMETRY_DRIVERS"); v != "" {
+		parsed, err := parseNumbers(v)
+		if err != nil {
+			return err
+		}
+		cfg.TelemetryDrivers = parsed
+	}
+	if v := os.Getenv("STATSHUB_TELEMETRY_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("STATSHUB_TELEMETRY_RATE inválido %q: %w", v, err)
+		}
+		cfg.TelemetryRate = rate
+	}
+
+	if v := os.Getenv("STATSHUB_ADMIN_TOKEN"); v != "" {
+		cfg.AdminToken = v
+	}
+
+	// 3. Flags (solo los que se pasaron explícitamente)
+	var flagErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "openf1-url":
+			cfg.OpenF1BaseURL = *baseURL
+		case "source":
+			cfg.Source = *src
+		case "source-dir":
+			cfg.SourceDir = *srcDir
+		case "sync-interval":
+			cfg.SyncInterval = Duration{*syncInterval}
+		case "years":
+			cfg.Years, flagErr = parseYears(*years)
+		case "telemetry-sessions":
+			cfg.TelemetrySessions, flagErr = parseNumbers(*telemetrySessions)
+		case "telemetry-drivers":
+			cfg.TelemetryDrivers, flagErr = parseNumbers(*telemetryDrivers)
+		case "telemetry-rate":
+			cfg.TelemetryRate = *telemetryRate
+		case "admin-token":
+			cfg.AdminToken = *adminToken
+		}
+	})
+	if flagErr != nil {
+		return flagErr
+	}
+
+	if cfg.Source == "" {
+		cfg.Source = sourceOpenF1
+	}
+	cfg.OpenF1BaseURL = strings.TrimRight(cfg.OpenF1BaseURL, "/")
+	if cfg.OpenF1BaseURL == "" {
+		return fmt.Errorf("la URL base de OpenF1 no puede estar vacía")
+	}
+
+	if len(cfg.Years) == 0 {
+		return fmt.Errorf("se debe configurar al menos una temporada")
+	}
+	sort.Ints(cfg.Years)
+
+	if cfg.SyncInterval.Duration < 0 {
+		return fmt.Errorf("el intervalo de sincronización no puede ser negativo")
+	}
+
+	if cfg.TelemetryRate <= 0 {
+		return fmt.Errorf("la frecuencia de telemetría debe ser mayor que cero")
+	}
+
+	return nil
+}
+
+// parseYears interpreta una lista de años separados por coma
+func parseYears(v string) ([]int, error) {
+	var years []int
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		year, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("año inválido %q: %w", part, err)
+		}
+		years = append(years, year)
+	}
+	return years, nil
+}
+
+// parseNumbers interpreta una lista de números separados por coma
+// (sesiones o pilotos)
+func parseNumbers(v string) ([]int, error) {
+	var numbers []int
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("número inválido %q: %w", part, err)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// defaultSeason es la temporada más reciente configurada
+func defaultSeason() int {
+	return cfg.Years[len(cfg.Years)-1]
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DataSource abstrae el origen de los datos con que se puebla proxy.db.
+// Las funciones autoPopulate* solo hablan con esta interfaz, así que se
+// puede poblar la base desde OpenF1, desde volcados JSON o desde memoria.
+type DataSource interface {
+	Drivers(sessionKey int) ([]Driver, error)
+	Sessions(year int, sessionName string) ([]Session, error)
+	Positions(sessionKey int) ([]Position, error)
+	Laps(sessionKey int) ([]Lap, error)
+	RaceControl(sessionKey int) ([]RaceControl, error)
+	Stints(sessionKey int) ([]Stint, error)
+	PitStops(sessionKey int) ([]PitStop, error)
+	Weather(sessionKey int) ([]Weather, error)
+	Intervals(sessionKey int) ([]Interval, error)
+	CarData(sessionKey int, driverNumber uint) ([]CarData, error)
+}
+
+// Fuente de datos global, elegida al iniciar
+var source DataSource
+
+const (
+	sourceOpenF1 = "openf1"
+	sourceDir    = "dir"
+	sourceMemory = "memory"
+)
+
+func newDataSource(c Config) (DataSource, error) {
+	switch c.Source {
+	case sourceOpenF1:
+		return &openF1Source{baseURL: c.OpenF1BaseURL}, nil
+	case sourceDir:
+		if c.SourceDir == "" {
+			return nil, fmt.Errorf("la fuente %q requiere un directorio (source_dir)", c.Source)
+		}
+		return &dirSource{dir: c.SourceDir}, nil
+	case sourceMemory:
+		if c.SourceDir == "" {
+			return newMemorySource(), nil
+		}
+		return loadMemorySource(c.SourceDir)
+	default:
+		return nil, fmt.Errorf("fuente de datos desconocida: %q", c.Source)
+	}
+}
+
+// === OpenF1 HTTP ===
+
+type openF1Source struct {
+	baseURL string
+}
+
+// get consulta un endpoint de OpenF1 y deserializa la respuesta en out
+func (s *openF1Source) get(endpoint string, params url.Values, out interface{}) error {
+	u := fmt.Sprintf("%s/%s?%s", s.baseURL, endpoint, params.Encode())
+
+	body, err := fetchWithRetry(u, 3)
+	if err != nil {
+		return fmt.Errorf("error consultando %s: %w", endpoint, err)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("error parseando %s: %v", endpoint, err)
+	}
+	return nil
+}
+
+// getOptional es como get, pero una consulta sin registros deja out vacío
+// en lugar de fallar. Se usa para los endpoints que no todas las sesiones
+// tienen (por ejemplo, una clasificación no tiene stints), igual que
+// dirSource.readOptional.
+func (s *openF1Source) getOptional(endpoint string, params url.Values, out interface{}) error {
+	err := s.get(endpoint, params, out)
+	if errors.Is(err, errNoRows) {
+		log.Printf("ℹ️ Sin registros de %s (%s)", endpoint, params.Encode())
+		return nil
+	}
+	return err
+}
+
+func (s *openF1Source) Drivers(sessionKey int) ([]Driver, error) {
+	var drivers []Driver
+	err := s.get("drivers", sessionParams(sessionKey), &drivers)
+	return drivers, err
+}
+
+func (s *openF1Source) Sessions(year int, sessionName string) ([]Session, error) {
+	params := url.Values{}
+	params.Set("session_name", sessionName)
+	params.Set("year", strconv.Itoa(year))
+
+	// Una temporada sin sesiones publicadas todavía no es un error
+	var sessions []Session
+	err := s.getOptional("sessions", params, &sessions)
+	return sessions, err
+}
+
+func (s *openF1Source) Positions(sessionKey int) ([]Position, error) {
+	log.Printf("🔍 Consultando posiciones de sesión %d", sessionKey)
+
+	var positions []Position
+	if err := s.get("position", sessionParams(sessionKey), &positions); err != nil {
+		return nil, err
+	}
+
+	// Asegurarse de que todas las posiciones tengan el session_key correcto
+	for i := range positions {
+		positions[i].SessionKey = sessionKey
+	}
+	return positions, nil
+}
+
+func (s *openF1Source) Laps(sessionKey int) ([]Lap, error) {
+	log.Printf("🔍 Consultando vueltas de sesión %d", sessionKey)
+
+	var laps []Lap
+	if err := s.get("laps", sessionParams(sessionKey), &laps); err != nil {
+		return nil, err
+	}
+
+	// Asegurarse de que todas las vueltas tengan el session_key correcto
+	for i := range laps {
+		laps[i].SessionKey = sessionKey
+	}
+	return laps, nil
+}
+
+func (s *openF1Source) RaceControl(sessionKey int) ([]RaceControl, error) {
+	log.Printf("🔍 Consultando dirección de carrera de sesión %d", sessionKey)
+
+	var messages []RaceControl
+	if err := s.getOptional("race_control", sessionParams(sessionKey), &messages); err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		messages[i].SessionKey = sessionKey
+	}
+	return messages, nil
+}
+
+func (s *openF1Source) Stints(sessionKey int) ([]Stint, error) {
+	log.Printf("🔍 Consultando stints de sesión %d", sessionKey)
+
+	var stints []Stint
+	if err := s.getOptional("stints", sessionParams(sessionKey), &stints); err != nil {
+		return nil, err
+	}
+
+	for i := range stints {
+		stints[i].SessionKey = sessionKey
+	}
+	return stints, nil
+}
+
+func (s *openF1Source) PitStops(sessionKey int) ([]PitStop, error) {
+	log.Printf("🔍 Consultando paradas en boxes de sesión %d", sessionKey)
+
+	var stops []PitStop
+	if err := s.getOptional("pit", sessionParams(sessionKey), &stops); err != nil {
+		return nil, err
+	}
+
+	for i := range stops {
+		stops[i].SessionKey = sessionKey
+	}
+	return stops, nil
+}
+
+func (s *openF1Source) Weather(sessionKey int) ([]Weather, error) {
+	log.Printf("🔍 Consultando clima de sesión %d", sessionKey)
+
+	var samples []Weather
+	if err := s.getOptional("weather", sessionParams(sessionKey), &samples); err != nil {
+		return nil, err
+	}
+
+	for i := range samples {
+		samples[i].SessionKey = sessionKey
+	}
+	return samples, nil
+}
+
+func (s *openF1Source) Intervals(sessionKey int) ([]Interval, error) {
+	log.Printf("🔍 Consultando intervalos de sesión %d", sessionKey)
+
+	var samples []Interval
+	if err := s.getOptional("intervals", sessionParams(sessionKey), &samples); err != nil {
+		return nil, err
+	}
+
+	for i := range samples {
+		samples[i].SessionKey = sessionKey
+	}
+	return samples, nil
+}
+
+func (s *openF1Source) CarData(sessionKey int, driverNumber uint) ([]CarData, error) {
+	log.Printf("🔍 Consultando telemetría del piloto %d en sesión %d", driverNumber, sessionKey)
+
+	params := sessionParams(sessionKey)
+	params.Set("driver_number", strconv.Itoa(int(driverNumber)))
+
+	var samples []CarData
+	if err := s.get("car_data", params, &samples); err != nil {
+		return nil, err
+	}
+
+	for i := range samples {
+		samples[i].SessionKey = sessionKey
+	}
+	return samples, nil
+}
+
+func sessionParams(sessionKey int) url.Values {
+	params := url.Values{}
+	params.Set("session_key", strconv.Itoa(sessionKey))
+	return params
+}
+
+// === Directorio de volcados JSON ===
+
+// dirSource lee archivos <endpoint>.json con el mismo formato que devuelve
+// OpenF1 (por ejemplo los fixtures de cmd/openf1-mock) y los filtra en cada
+// llamada.
+type dirSource struct {
+	dir string
+}
+
+// read carga <endpoint>.json, se queda con los registros cuyos campos
+// coinciden con filters y los deserializa en out
+func (s *dirSource) read(endpoint string, filters map[string]string, out interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, endpoint+".json"))
+	if err != nil {
+		return fmt.Errorf("error leyendo volcado de %s: %v", endpoint, err)
+	}
+	return s.decode(endpoint, data, filters, out)
+}
+
+// readOptional es como read, pero un volcado inexistente cuenta como vacío.
+// Se usa para los endpoints que los volcados antiguos no traen.
+func (s *dirSource) readOptional(endpoint string, filters map[string]string, out interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, endpoint+".json"))
+	if os.IsNotExist(err) {
+		data = []byte("[]")
+	} else if err != nil {
+		return fmt.Errorf("error leyendo volcado de %s: %v", endpoint, err)
+	}
+	return s.decode(endpoint, data, filters, out)
+}
+
+// decode filtra los registros de un volcado y los deserializa en out
+func (s *dirSource) decode(endpoint string, data []byte, filters map[string]string, out interface{}) error {
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("error parseando volcado de %s: %v", endpoint, err)
+	}
+
+	filtered := make([]map[string]interface{}, 0, len(records))
+	for _, rec := range records {
+		match := true
+		for field, want := range filters {
+			if v, ok := rec[field]; !ok || fmt.Sprint(v) != want {
+				match = false
+				break
+			}
+		}
+		if match {
+			filtered = append(filtered, rec)
+		}
+	}
+
+	// Volver a serializar para reutilizar las etiquetas json de los modelos
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (s *dirSource) Drivers(sessionKey int) ([]Driver, error) {
+	var drivers []Driver
+	err := s.read("drivers", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &drivers)
+	return drivers, err
+}
+
+func (s *dirSource) Sessions(year int, sessionName string) ([]Session, error) {
+	var sessions []Session
+	err := s.read("sessions", map[string]string{"session_name": sessionName, "year": strconv.Itoa(year)}, &sessions)
+	return sessions, err
+}
+
+func (s *dirSource) Positions(sessionKey int) ([]Position, error) {
+	var positions []Position
+	err := s.read("position", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &positions)
+	return positions, err
+}
+
+func (s *dirSource) Laps(sessionKey int) ([]Lap, error) {
+	var laps []Lap
+	err := s.read("laps", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &laps)
+	return laps, err
+}
+
+func (s *dirSource) RaceControl(sessionKey int) ([]RaceControl, error) {
+	var messages []RaceControl
+	err := s.readOptional("race_control", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &messages)
+	return messages, err
+}
+
+func (s *dirSource) Stints(sessionKey int) ([]Stint, error) {
+	var stints []Stint
+	err := s.readOptional("stints", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &stints)
+	return stints, err
+}
+
+func (s *dirSource) PitStops(sessionKey int) ([]PitStop, error) {
+	var stops []PitStop
+	err := s.readOptional("pit", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &stops)
+	return stops, err
+}
+
+func (s *dirSource) Weather(sessionKey int) ([]Weather, error) {
+	var samples []Weather
+	err := s.readOptional("weather", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &samples)
+	return samples, err
+}
+
+func (s *dirSource) Intervals(sessionKey int) ([]Interval, error) {
+	var samples []Interval
+	err := s.readOptional("intervals", map[string]string{"session_key": strconv.Itoa(sessionKey)}, &samples)
+	return samples, err
+}
+
+func (s *dirSource) CarData(sessionKey int, driverNumber uint) ([]CarData, error) {
+	var samples []CarData
+	err := s.readOptional("car_data", map[string]string{
+		"session_key":   strconv.Itoa(sessionKey),
+		"driver_number": strconv.Itoa(int(driverNumber)),
+	}, &samples)
+	return samples, err
+}
+
+// === En memoria ===
+
+// memorySource guarda todos los datos en memoria. Sirve como fake para
+// pruebas y demos: se puede armar a mano o cargar de un directorio.
+type memorySource struct {
+	drivers     map[int][]Driver // por session_key
+	sessions    []Session
+	positions   []Position
+	laps        []Lap
+	raceControl []RaceControl
+	stints      []Stint
+	pitStops    []PitStop
+	weather     []Weather
+	intervals   []Interval
+	carData     []CarData
+}
+
+func newMemorySource() *memorySource {
+	return &memorySource{drivers: make(map[int][]Driver)}
+}
+
+// loadMemorySource toma una foto de un directorio de volcados al iniciar
+func loadMemorySource(dir string) (*memorySource, error) {
+	d := &dirSource{dir: dir}
+	m := newMemorySource()
+
+	if err := d.read("sessions", nil, &m.sessions); err != nil {
+		return nil, err
+	}
+	if err := d.read("position", nil, &m.positions); err != nil {
+		return nil, err
+	}
+	if err := d.read("laps", nil, &m.laps); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("race_control", nil, &m.raceControl); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("stints", nil, &m.stints); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("pit", nil, &m.pitStops); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("weather", nil, &m.weather); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("intervals", nil, &m.intervals); err != nil {
+		return nil, err
+	}
+	if err := d.readOptional("car_data", nil, &m.carData); err != nil {
+		return nil, err
+	}
+	for _, s := range m.sessions {
+		drivers, err := d.Drivers(s.SessionKey)
+		if err != nil {
+			return nil, err
+		}
+		m.drivers[s.SessionKey] = drivers
+	}
+
+	log.Printf("📦 Fuente en memoria cargada desde %s: %d sesiones, %d posiciones, %d vueltas",
+		dir, len(m.sessions), len(m.positions), len(m.laps))
+	return m, nil
+}
+
+func (m *memorySource) Drivers(sessionKey int) ([]Driver, error) {
+	return m.drivers[sessionKey], nil
+}
+
+func (m *memorySource) Sessions(year int, sessionName string) ([]Session, error) {
+	var out []Session
+	for _, s := range m.sessions {
+		if s.Year == year && s.SessionName == sessionName {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) Positions(sessionKey int) ([]Position, error) {
+	var out []Position
+	for _, p := range m.positions {
+		if p.SessionKey == sessionKey {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) Laps(sessionKey int) ([]Lap, error) {
+	var out []Lap
+	for _, l := range m.laps {
+		if l.SessionKey == sessionKey {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) RaceControl(sessionKey int) ([]RaceControl, error) {
+	var out []RaceControl
+	for _, rc := range m.raceControl {
+		if rc.SessionKey == sessionKey {
+			out = append(out, rc)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) Stints(sessionKey int) ([]Stint, error) {
+	var out []Stint
+	for _, st := range m.stints {
+		if st.SessionKey == sessionKey {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) PitStops(sessionKey int) ([]PitStop, error) {
+	var out []PitStop
+	for _, p := range m.pitStops {
+		if p.SessionKey == sessionKey {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) Weather(sessionKey int) ([]Weather, error) {
+	var out []Weather
+	for _, w := range m.weather {
+		if w.SessionKey == sessionKey {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) Intervals(sessionKey int) ([]Interval, error) {
+	var out []Interval
+	for _, iv := range m.intervals {
+		if iv.SessionKey == sessionKey {
+			out = append(out, iv)
+		}
+	}
+	return out, nil
+}
+
+func (m *memorySource) CarData(sessionKey int, driverNumber uint) ([]CarData, error) {
+	var out []CarData
+	for _, cd := range m.carData {
+		if cd.SessionKey == sessionKey && cd.DriverNumber == driverNumber {
+			out = append(out, cd)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useTestDB reemplaza la base global por una proxy.db vacía en un
+// directorio temporal, con todas las tablas creadas
+func useTestDB(t *testing.T) {
+	t.Helper()
+
+	testDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "proxy.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("abriendo base de prueba: %v", err)
+	}
+	if err := testDB.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrando base de prueba: %v", err)
+	}
+
+	prev := db
+	db = testDB
+	t.Cleanup(func() {
+		if sqlDB, err := testDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+		db = prev
+	})
+}
+
+// raceStart es la hora de largada de las carreras de prueba
+var raceStart = time.Date(2024, 7, 28, 13, 3, 0, 0, time.UTC)
+
+// openF1Time formatea un instante relativo a la largada como lo manda
+// OpenF1
+func openF1Time(seconds float64) string {
+	t := raceStart.Add(time.Duration(seconds * float64(time.Second)))
+	return t.Format("2006-01-02T15:04:05.000000") + "+00:00"
+}
+
+// raceLaps arma las vueltas de un piloto: la vuelta 1 empieza en
+// firstLapStart sin duración (como en OpenF1) y termina en lap2Start; las
+// demás duran lapTime. Las vueltas de skip quedan sin duración.
+func raceLaps(sessionKey int, driver uint, laps int, firstLapStart, lap2Start, lapTime float64, skip ...int) []Lap {
+	skipped := make(map[int]bool, len(skip))
+	for _, n := range skip {
+		skipped[n] = true
+	}
+
+	out := make([]Lap, 0, laps)
+	for n := 1; n <= laps; n++ {
+		l := Lap{SessionKey: sessionKey, DriverNumber: driver, LapNumber: n}
+		if n == 1 {
+			l.DateStart = openF1Time(firstLapStart)
+		} else {
+			l.DateStart = openF1Time(lap2Start + lapTime*float64(n-2))
+			if !skipped[n] {
+				l.LapDuration = lapTime
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
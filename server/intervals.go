@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Interval es una muestra de OpenF1 (cada pocos segundos, solo en carreras)
+// con la diferencia de un piloto con el líder y con el auto de adelante.
+// Si la diferencia es de una o más vueltas OpenF1 manda un texto ("+1 LAP"):
+// se guarda en LapsToLeader/LapsToAhead y la diferencia en segundos queda
+// en cero.
+type Interval struct {
+	SessionKey   int     `json:"session_key" gorm:"uniqueIndex:idx_intervals_natural,priority:1"`
+	DriverNumber uint    `json:"driver_number" gorm:"uniqueIndex:idx_intervals_natural,priority:2"`
+	Date         string  `json:"date" gorm:"uniqueIndex:idx_intervals_natural,priority:3"`
+	GapToLeader  float64 `json:"gap_to_leader"`
+	LapsToLeader int     `json:"laps_to_leader"`
+	GapToAhead   float64 `json:"interval"`
+	LapsToAhead  int     `json:"laps_to_ahead"`
+}
+
+// UnmarshalJSON acepta las diferencias de OpenF1 en segundos, null o como
+// texto de vueltas
+func (iv *Interval) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		SessionKey   int             `json:"session_key"`
+		DriverNumber uint            `json:"driver_number"`
+		Date         string          `json:"date"`
+		GapToLeader  json.RawMessage `json:"gap_to_leader"`
+		Interval     json.RawMessage `json:"interval"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	iv.SessionKey = raw.SessionKey
+	iv.DriverNumber = raw.DriverNumber
+	iv.Date = raw.Date
+	iv.GapToLeader, iv.LapsToLeader = parseGap(raw.GapToLeader)
+	iv.GapToAhead, iv.LapsToAhead = parseGap(raw.Interval)
+	return nil
+}
+
+// parseGap interpreta una diferencia de OpenF1: segundos, null (el líder,
+// o sin dato) o un texto como "+1 LAP" / "+2 LAPS"
+func parseGap(raw json.RawMessage) (float64, int) {
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err == nil {
+		return seconds, 0
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return 0, 0
+	}
+	laps := 0
+	fmt.Sscanf(strings.TrimSpace(text), "+%d LAP", &laps)
+	return 0, laps
+}
+
+// gapValue devuelve la diferencia para la respuesta: segundos, o nil si es
+// de una o más vueltas
+func gapValue(seconds float64, laps int) interface{} {
+	if laps > 0 {
+		return nil
+	}
+	return seconds
+}
+
+// lapAt devuelve la vuelta del líder en curso en el instante t (0 antes de
+// la largada), con las horas de inicio de leaderLapStarts
+func lapAt(lapStarts []leaderLap, t time.Time) int {
+	i := sort.Search(len(lapStarts), func(i int) bool { return lapStarts[i].Start.After(t) })
+	if i == 0 {
+		return 0
+	}
+	return lapStarts[i-1].LapNumber
+}
+
+// finalGaps devuelve la diferencia final con el ganador de cada piloto
+// clasificado, la misma que guarda la clasificación (con los intervalos de
+// OpenF1 si la carrera los tiene). Los doblados y los que abandonaron
+// (aunque se clasifiquen) solo llevan las vueltas de diferencia.
+func finalGaps(sessionKey int, rows []Classification) []gin.H {
+	if len(rows) == 0 || !rows[0].Classified {
+		return nil
+	}
+
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+	gaps := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		if !r.Classified {
+			continue
+		}
+		var gap interface{}
+		if r.Status == classificationFinished && r.LapsBehind == 0 {
+			gap = r.GapToWinner
+		}
+
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		gaps = append(gaps, gin.H{
+			"position":      r.Position,
+			"driver_number": r.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"status":        r.Status,
+			"gap_to_winner": gap,
+			"laps_behind":   r.LapsBehind,
+		})
+	}
+	return gaps
+}
+
+// GET /api/carrera/intervalos/:id
+func getRaceIntervals(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	var samples []Interval
+	if err := db.Where("session_key = ?", sessionKey).Order("date ASC").Find(&samples).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los intervalos"})
+		return
+	}
+
+	// Por vuelta del líder se queda la última muestra de cada piloto: la
+	// diferencia al cerrar esa vuelta
+	lapStarts := leaderLapStarts(sessionKey)
+	byDriver := make(map[uint]map[int]Interval)
+	for _, iv := range samples {
+		t, ok := parseOpenF1Time(iv.Date)
+		if !ok {
+			continue
+		}
+		lap := lapAt(lapStarts, t)
+		if lap == 0 {
+			continue
+		}
+		if byDriver[iv.DriverNumber] == nil {
+			byDriver[iv.DriverNumber] = make(map[int]Interval)
+		}
+		byDriver[iv.DriverNumber][lap] = iv
+	}
+
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
+		return
+	}
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	out := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		perLap := make([]gin.H, 0, len(lapStarts))
+		for lap := 1; lap <= len(lapStarts); lap++ {
+			iv, ok := byDriver[r.DriverNumber][lap]
+			if !ok {
+				continue
+			}
+			perLap = append(perLap, gin.H{
+				"lap_number":     lap,
+				"gap_to_leader":  gapValue(iv.GapToLeader, iv.LapsToLeader),
+				"laps_to_leader": iv.LapsToLeader,
+				"interval":       gapValue(iv.GapToAhead, iv.LapsToAhead),
+				"laps_to_ahead":  iv.LapsToAhead,
+			})
+		}
+
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		out = append(out, gin.H{
+			"driver_number": r.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"name_acronym":  d.NameAcronym,
+			"team":          team,
+			"team_colour":   teamColour,
+			"position":      r.Position,
+			"status":        r.Status,
+			"laps":          perLap,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"total_laps":         len(lapStarts),
+		"drivers":            out,
+	})
+}
+
+// GET /api/carrera/intervalos/:id/:driver
+func getDriverIntervals(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+	driverNumber, err := strconv.Atoi(c.Param("driver"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Número de piloto inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+	var driver Driver
+	if err := db.First(&driver, "driver_number = ?", driverNumber).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Piloto no encontrado"})
+		return
+	}
+
+	var samples []Interval
+	if err := db.Where("session_key = ? AND driver_number = ?", sessionKey, driverNumber).Order("date ASC").Find(&samples).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los intervalos"})
+		return
+	}
+
+	// Todas las muestras, cada una con la vuelta en curso del líder
+	lapStarts := leaderLapStarts(sessionKey)
+	series := make([]gin.H, 0, len(samples))
+	closest := -1.0
+	for _, iv := range samples {
+		lap := 0
+		if t, ok := parseOpenF1Time(iv.Date); ok {
+			lap = lapAt(lapStarts, t)
+		}
+		if lap > 0 && iv.LapsToAhead == 0 && iv.GapToAhead > 0 && (closest < 0 || iv.GapToAhead < closest) {
+			closest = iv.GapToAhead
+		}
+		series = append(series, gin.H{
+			"date":           iv.Date,
+			"lap_number":     lap,
+			"gap_to_leader":  gapValue(iv.GapToLeader, iv.LapsToLeader),
+			"laps_to_leader": iv.LapsToLeader,
+			"interval":       gapValue(iv.GapToAhead, iv.LapsToAhead),
+			"laps_to_ahead":  iv.LapsToAhead,
+		})
+	}
+
+	// Menor distancia con el auto de adelante en carrera; nil si no hubo
+	var closestInterval interface{}
+	if closest >= 0 {
+		closestInterval = closest
+	}
+
+	team, teamColour := teamOf(sessionEntries(sessionKey), driver)
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":          session.SessionKey,
+		"country_name":     session.CountryName,
+		"driver_number":    driver.DriverNumber,
+		"driver":           fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+		"team":             team,
+		"team_colour":      teamColour,
+		"closest_interval": closestInterval,
+		"samples":          series,
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseGap(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantSeconds float64
+		wantLaps    int
+	}{
+		{`2.761`, 2.761, 0},
+		{`0`, 0, 0},
+		{`null`, 0, 0},
+		{`"+1 LAP"`, 0, 1},
+		{`"+3 LAPS"`, 0, 3},
+		{`" +2 LAPS "`, 0, 2},
+		{`"???"`, 0, 0},
+		{``, 0, 0},
+	}
+	for _, tt := range tests {
+		seconds, laps := parseGap(json.RawMessage(tt.raw))
+		if seconds != tt.wantSeconds || laps != tt.wantLaps {
+			t.Errorf("parseGap(%s) = %v, %d; want %v, %d", tt.raw, seconds, laps, tt.wantSeconds, tt.wantLaps)
+		}
+	}
+}
+
+func TestIntervalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Interval
+	}{
+		{
+			name: "en segundos",
+			data: `{"session_key": 9574, "driver_number": 81, "date": "2024-07-28T14:25:01.123000+00:00", "gap_to_leader": 2.761, "interval": 1.004}`,
+			want: Interval{SessionKey: 9574, DriverNumber: 81, Date: "2024-07-28T14:25:01.123000+00:00", GapToLeader: 2.761, GapToAhead: 1.004},
+		},
+		{
+			name: "el líder",
+			data: `{"session_key": 9574, "driver_number": 44, "date": "2024-07-28T14:25:00.000000+00:00", "gap_to_leader": null, "interval": null}`,
+			want: Interval{SessionKey: 9574, DriverNumber: 44, Date: "2024-07-28T14:25:00.000000+00:00"},
+		},
+		{
+			name: "doblado",
+			data: `{"session_key": 9574, "driver_number": 2, "date": "2024-07-28T14:25:02.000000+00:00", "gap_to_leader": "+2 LAPS", "interval": "+1 LAP"}`,
+			want: Interval{SessionKey: 9574, DriverNumber: 2, Date: "2024-07-28T14:25:02.000000+00:00", LapsToLeader: 2, LapsToAhead: 1},
+		},
+		{
+			name: "doblado por el líder pero cerca del de adelante",
+			data: `{"session_key": 9574, "driver_number": 24, "date": "2024-07-28T14:25:03.000000+00:00", "gap_to_leader": "+1 LAP", "interval": 0.35}`,
+			want: Interval{SessionKey: 9574, DriverNumber: 24, Date: "2024-07-28T14:25:03.000000+00:00", LapsToLeader: 1, GapToAhead: 0.35},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Interval
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	var iv Interval
+	if err := json.Unmarshal([]byte(`[1, 2]`), &iv); err == nil {
+		t.Error("se esperaba error con un JSON que no es un objeto")
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tipos y estados de los jobs de sincronización
+const (
+	jobFull      = "full"
+	jobSession   = "session"
+	jobScheduled = "scheduled"
+
+	jobRunning  = "running"
+	jobComplete = "complete"
+	jobFailed   = "failed"
+)
+
+// Estados del progreso de cada sesión dentro de un job
+const (
+	progressQueued    = "queued"
+	progressFetching  = "fetching"
+	progressInserting = "inserting"
+	progressComplete  = "complete"
+	progressFailed    = "failed"
+)
+
+// Cantidad de jobs terminados que se recuerdan
+const maxFinishedJobs = 100
+
+var errSyncRunning = errors.New("ya hay una sincronización completa en curso")
+
+// sessionProgress es el avance de una sesión dentro de un job
+type sessionProgress struct {
+	SessionKey       int      `json:"session_key"`
+	Status           string   `json:"status"`
+	PositionsFetched int      `json:"positions_fetched"`
+	LapsFetched      int      `json:"laps_fetched"`
+	RowsInserted     int      `json:"rows_inserted"`
+	Errors           []string `json:"errors"`
+}
+
+// syncJob es una ejecución de la sincronización que se puede seguir desde
+// la API mientras corre en segundo plano
+type syncJob struct {
+	mu         sync.Mutex
+	id         string
+	kind       string
+	status     string
+	force      bool
+	startedAt  time.Time
+	finishedAt *time.Time
+	summary    *syncSummary
+	err        string
+	sessions   map[int]*sessionProgress
+	order      []int
+}
+
+// queue agrega una sesión al job como pendiente. Un job nil no hace nada,
+// así el pipeline funciona igual cuando nadie sigue el progreso.
+func (j *syncJob) queue(s Session) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.sessions[s.SessionKey]; !ok {
+		j.sessions[s.SessionKey] = &sessionProgress{SessionKey: s.SessionKey, Status: progressQueued, Errors: []string{}}
+		j.order = append(j.order, s.SessionKey)
+	}
+}
+
+// update modifica el progreso de una sesión del job
+func (j *syncJob) update(sessionKey int, fn func(p *sessionProgress)) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	p, ok := j.sessions[sessionKey]
+	if !ok {
+		p = &sessionProgress{SessionKey: sessionKey, Status: progressQueued, Errors: []string{}}
+		j.sessions[sessionKey] = p
+		j.order = append(j.order, sessionKey)
+	}
+	fn(p)
+}
+
+// snapshot devuelve el estado del job para la API
+func (j *syncJob) snapshot(withSessions bool) gin.H {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out := gin.H{
+		"id":          j.id,
+		"kind":        j.kind,
+		"status":      j.status,
+		"force":       j.force,
+		"started_at":  j.startedAt,
+		"finished_at": j.finishedAt,
+		"summary":     j.summary,
+		"error":       j.err,
+	}
+
+	if withSessions {
+		sessions := make([]sessionProgress, 0, len(j.order))
+		for _, key := range j.order {
+			p := *j.sessions[key]
+			p.Errors = append([]string{}, p.Errors...)
+			sessions = append(sessions, p)
+		}
+		out["sessions"] = sessions
+	}
+	return out
+}
+
+// syncJobRegistry guarda los jobs en memoria y garantiza que no corran dos
+// sincronizaciones completas a la vez
+type syncJobRegistry struct {
+	mu     sync.Mutex
+	nextID int
+	jobs   map[string]*syncJob
+	order  []string
+	active *syncJob
+}
+
+// Registro global de jobs
+var jobs = &syncJobRegistry{jobs: make(map[string]*syncJob)}
+
+// create registra un job nuevo. Los jobs completos y programados son
+// exclusivos entre sí; los de una sola sesión pueden correr en paralelo.
+func (r *syncJobRegistry) create(kind string, force bool) (*syncJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exclusive := kind == jobFull || kind == jobScheduled
+	if exclusive && r.active != nil {
+		return r.active, errSyncRunning
+	}
+
+	r.nextID++
+	j := &syncJob{
+		id:        strconv.Itoa(r.nextID),
+		kind:      kind,
+		status:    jobRunning,
+		force:     force,
+		startedAt: time.Now(),
+		sessions:  make(map[int]*sessionProgress),
+	}
+	r.jobs[j.id] = j
+	r.order = append(r.order, j.id)
+	if exclusive {
+		r.active = j
+	}
+
+	r.prune()
+	return j, nil
+}
+
+// finish cierra el job con el resultado de la sincronización
+func (r *syncJobRegistry) finish(j *syncJob, summary syncSummary, err error) {
+	now := time.Now()
+	j.mu.Lock()
+	j.finishedAt = &now
+	j.summary = &summary
+	j.status = jobComplete
+	if err != nil {
+		j.status = jobFailed
+		j.err = err.Error()
+	}
+	j.mu.Unlock()
+
+	r.mu.Lock()
+	if r.active == j {
+		r.active = nil
+	}
+	r.mu.Unlock()
+
+	log.Printf("📋 Job %s (%s) terminado: %s", j.id, j.kind, j.status)
+}
+
+func (r *syncJobRegistry) get(id string) *syncJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id]
+}
+
+func (r *syncJobRegistry) list() []*syncJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]*syncJob, 0, len(r.order))
+	for i := len(r.order) - 1; i >= 0; i-- {
+		out = append(out, r.jobs[r.order[i]])
+	}
+	return out
+}
+
+// prune olvida los jobs terminados más antiguos (con r.mu tomado)
+func (r *syncJobRegistry) prune() {
+	for len(r.order) > maxFinishedJobs {
+		oldest := r.jobs[r.order[0]]
+		oldest.mu.Lock()
+		running := oldest.status == jobRunning
+		oldest.mu.Unlock()
+		if running {
+			return
+		}
+		delete(r.jobs, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// failedSessionsError devuelve el error con que termina un job: el de la
+// sincronización y, si hubo sesiones fallidas, cuántas fueron
+func failedSessionsError(summary syncSummary, err error) error {
+	if summary.Failed == 0 {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w; %d sesiones fallidas", err, summary.Failed)
+	}
+	return fmt.Errorf("%d sesiones fallidas", summary.Failed)
+}
+
+// requireAdmin protege la API de administración: con admin_token
+// configurado exige "Authorization: Bearer <token>"; sin token solo acepta
+// pedidos que llegan desde la misma máquina
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.AdminToken != "" {
+			token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminToken)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de administración inválido"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "La API de administración solo acepta pedidos locales sin admin_token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Handlers
+
+// POST /api/admin/sync?force=true
+func postSync(c *gin.Context) {
+	force := c.Query("force") == "true"
+
+	job, err := jobs.create(jobFull, force)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "job_id": job.id})
+		return
+	}
+
+	go func() {
+		summary, err := runSync(job, force)
+		jobs.finish(job, summary, failedSessionsError(summary, err))
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":     job.id,
+		"status_url": "/api/admin/sync/jobs/" + job.id,
+	})
+}
+
+// POST /api/admin/sync/session/:id
+func postSyncSession(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de sesión inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesión no encontrada"})
+		return
+	}
+
+	job, _ := jobs.create(jobSession, true)
+	job.queue(session)
+
+	go func() {
+		var summary syncSummary
+		var err error
+		if state := syncSession(session, job); state.Status == syncComplete {
+			summary.Completed = 1
+		} else {
+			summary.Failed = 1
+			err = fmt.Errorf("sesión %d: %s", session.SessionKey, state.Error)
+		}
+		jobs.finish(job, summary, err)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":     job.id,
+		"status_url": "/api/admin/sync/jobs/" + job.id,
+	})
+}
+
+// GET /api/admin/sync/jobs
+func getSyncJobs(c *gin.Context) {
+	list := jobs.list()
+	out := make([]gin.H, 0, len(list))
+	for _, j := range list {
+		out = append(out, j.snapshot(false))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /api/admin/sync/jobs/:id
+func getSyncJob(c *gin.Context) {
+	job := jobs.get(c.Param("id"))
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job no encontrado"})
+		return
+	}
+
+	out := job.snapshot(true)
+
+	// Ordenar el progreso por session_key para que sea estable entre consultas
+	sessions := out["sessions"].([]sessionProgress)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].SessionKey < sessions[j].SessionKey
+	})
+
+	c.JSON(http.StatusOK, out)
+}
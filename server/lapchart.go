@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// positionSample es una muestra de posición con su hora ya parseada
+type positionSample struct {
+	At       time.Time
+	Position int
+}
+
+// parseOpenF1Time interpreta las fechas ISO 8601 de OpenF1, con o sin
+// fracción de segundo
+func parseOpenF1Time(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// positionTimeline devuelve las muestras de posición de cada piloto de una
+// sesión en orden cronológico
+func positionTimeline(sessionKey int) (map[uint][]positionSample, error) {
+	var positions []Position
+	if err := db.Where("session_key = ?", sessionKey).Order("date ASC").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	timeline := make(map[uint][]positionSample)
+	for _, p := range positions {
+		at, ok := parseOpenF1Time(p.Date)
+		if !ok {
+			continue
+		}
+		timeline[p.DriverNumber] = append(timeline[p.DriverNumber], positionSample{At: at, Position: p.Position})
+	}
+	return timeline, nil
+}
+
+// positionAt devuelve la última posición conocida en el instante t (0 si
+// todavía no había muestras)
+func positionAt(samples []positionSample, t time.Time) int {
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].At.After(t) })
+	if i == 0 {
+		return 0
+	}
+	return samples[i-1].Position
+}
+
+// lapEndTimes devuelve la hora en que cada piloto cerró cada vuelta: el
+// inicio de la siguiente o, en la última, su inicio más la duración
+func lapEndTimes(sessionKey int) (map[uint]map[int]time.Time, error) {
+	var laps []Lap
+	if err := db.Where("session_key = ?", sessionKey).Order("driver_number ASC, lap_number ASC").Find(&laps).Error; err != nil {
+		return nil, err
+	}
+
+	ends := make(map[uint]map[int]time.Time)
+	for i, l := range laps {
+		if ends[l.DriverNumber] == nil {
+			ends[l.DriverNumber] = make(map[int]time.Time)
+		}
+
+		if i+1 < len(laps) && laps[i+1].DriverNumber == l.DriverNumber && laps[i+1].LapNumber == l.LapNumber+1 {
+			if next, ok := parseOpenF1Time(laps[i+1].DateStart); ok {
+				ends[l.DriverNumber][l.LapNumber] = next
+				continue
+			}
+		}
+		if start, ok := parseOpenF1Time(l.DateStart); ok && l.LapDuration > 0 {
+			ends[l.DriverNumber][l.LapNumber] = start.Add(time.Duration(l.LapDuration * float64(time.Second)))
+		}
+	}
+	return ends, nil
+}
+
+// GET /api/carrera/vueltas/:id
+func getLapChart(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	timeline, err := positionTimeline(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las posiciones"})
+		return
+	}
+	ends, err := lapEndTimes(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las vueltas"})
+		return
+	}
+
+	totalLaps := 0
+	for _, byLap := range ends {
+		for lap := range byLap {
+			if lap > totalLaps {
+				totalLaps = lap
+			}
+		}
+	}
+
+	// Vueltas del líder corridas bajo safety car, VSC o bandera roja
+	messages, err := sessionRaceControl(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los mensajes de dirección de carrera"})
+		return
+	}
+	periods := neutralizedPeriods(messages)
+	lapStarts := leaderLapStarts(sessionKey)
+	neutralizedLaps := make([]gin.H, 0)
+	for i, l := range lapStarts {
+		// La vuelta dura hasta el inicio de la siguiente, si se conoce
+		duration := 0.0
+		if i+1 < len(lapStarts) && lapStarts[i+1].LapNumber == l.LapNumber+1 {
+			duration = lapStarts[i+1].Start.Sub(l.Start).Seconds()
+		}
+		if kind := lapNeutralization(periods, l.Start, duration); kind != "" {
+			neutralizedLaps = append(neutralizedLaps, gin.H{"lap_number": l.LapNumber, "type": kind})
+		}
+	}
+
+	// Pilotos en el orden de la clasificación final
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
+		return
+	}
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	out := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		samples := timeline[r.DriverNumber]
+
+		// positions[i] es la posición al cerrar la vuelta i+1; null si el
+		// piloto no completó esa vuelta
+		positions := make([]interface{}, totalLaps)
+		for lap := 1; lap <= totalLaps; lap++ {
+			if end, ok := ends[r.DriverNumber][lap]; ok {
+				if pos := positionAt(samples, end); pos > 0 {
+					positions[lap-1] = pos
+				}
+			}
+		}
+
+		grid := 0
+		if len(samples) > 0 {
+			grid = samples[0].Position
+		}
+
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		out = append(out, gin.H{
+			"driver_number": r.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"name_acronym":  d.NameAcronym,
+			"team":          team,
+			"team_colour":   teamColour,
+			"grid":          grid,
+			"final":         r.Position,
+			"status":        r.Status,
+			"positions":     positions,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":          session.SessionKey,
+		"total_laps":       totalLaps,
+		"neutralized_laps": neutralizedLaps,
+		"drivers":          out,
+	})
+}
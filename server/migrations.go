@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration registra las migraciones de datos ya aplicadas
+type SchemaMigration struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+// migration es un cambio de datos que se aplica una sola vez por proxy.db.
+// Corren antes de AutoMigrate, así que deben tolerar tablas inexistentes.
+type migration struct {
+	id  string
+	run func(tx *gorm.DB) error
+}
+
+var migrations = []migration{
+	{id: "001_dedupe_positions_laps", run: dedupePositionsAndLaps},
+	{id: "002_build_classifications", run: buildMissingClassifications},
+	{id: "009_rebuild_classifications", run: buildMissingClassifications},
+	{id: "010_resync_race_sessions", run: resyncRaceSessions},
+	{id: "011_rebuild_classification_times", run: buildMissingClassifications},
+	{id: "012_classification_grid_positions", run: buildMissingClassifications},
+}
+
+func runMigrations() error {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var count int64
+		db.Model(&SchemaMigration{}).Where("id = ?", m.id).Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		log.Printf("🛠️ Aplicando migración %s...", m.id)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: m.id, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migración %s: %w", m.id, err)
+		}
+	}
+	return nil
+}
+
+// dedupePositionsAndLaps borra las filas repetidas que dejaban las
+// ingestas anteriores a las claves naturales (se conserva la primera), para
+// que se puedan crear los índices únicos
+func dedupePositionsAndLaps(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(&Position{}) {
+		result := tx.Exec(`
+            DELETE FROM positions
+            WHERE rowid NOT IN (
+                SELECT MIN(rowid) FROM positions
+                GROUP BY session_key, driver_number, date
+            )
+        `)
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("🧹 %d posiciones duplicadas eliminadas", result.RowsAffected)
+	}
+
+	if tx.Migrator().HasTable(&Lap{}) {
+		result := tx.Exec(`
+            DELETE FROM laps
+            WHERE rowid NOT IN (
+                SELECT MIN(rowid) FROM laps
+                GROUP BY session_key, driver_number, lap_number
+            )
+        `)
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("🧹 %d vueltas duplicadas eliminadas", result.RowsAffected)
+	}
+
+	// Corregir los conteos guardados por la sincronización
+	if tx.Migrator().HasTable(&SyncState{}) {
+		return tx.Exec(`
+            UPDATE sync_states
+            SET positions = (SELECT COUNT(*) FROM positions p WHERE p.session_key = sync_states.session_key),
+                laps = (SELECT COUNT(*) FROM laps l WHERE l.session_key = sync_states.session_key)
+            WHERE status = ?
+        `, syncComplete).Error
+	}
+	return nil
+}
+
+// buildMissingClassifications calcula la clasificación final de las
+// sesiones ingeridas antes de que existiera la tabla classifications (o
+// antes de un cambio en cómo se calcula, como el tiempo total)
+func buildMissingClassifications(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable(&Position{}) || !tx.Migrator().HasTable(&Session{}) {
+		return nil
+	}
+	// buildClassification también lee los mensajes de dirección de carrera
+	// y los intervalos, y las migraciones corren antes del AutoMigrate general
+	if err := tx.AutoMigrate(&Classification{}, &RaceControl{}, &Interval{}); err != nil {
+		return err
+	}
+
+	var sessions []Session
+	err := tx.Where("session_key IN (?)", tx.Model(&Position{}).Distinct("session_key")).
+		Find(&sessions).Error
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if err := buildClassification(tx, s); err != nil {
+			return fmt.Errorf("sesión %d: %w", s.SessionKey, err)
+		}
+	}
+	log.Printf("🏁 Clasificación calculada para %d sesiones existentes", len(sessions))
+	return nil
+}
+
+// resyncRaceSessions deja pendientes las carreras y sprints ya ingeridos
+// para que la próxima sincronización descargue los datos que se empezaron a
+// ingerir después (dirección de carrera, vueltas de salida de boxes,
+// stints, paradas, clima, intervalos) y recalcule su clasificación. En una
+// proxy.db sin sync_states crea la tabla y los estados pendientes; si no,
+// adoptExistingSessions las marcaría como completas con los datos viejos.
+func resyncRaceSessions(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable(&Session{}) {
+		return nil
+	}
+	if err := tx.AutoMigrate(&SyncState{}); err != nil {
+		return err
+	}
+
+	result := tx.Exec(`
+        INSERT INTO sync_states (session_key, status, error)
+        SELECT session_key, ?, ''
+        FROM sessions
+        WHERE session_name IN (?, ?)
+        ON CONFLICT (session_key) DO UPDATE SET status = excluded.status
+    `, syncPending, sessionRace, sessionSprint)
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Printf("🔁 %d carreras marcadas para volver a sincronizar", result.RowsAffected)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// positionChange resume cómo se movió un piloto durante una carrera
+type positionChange struct {
+	DriverNumber uint
+	Grid         int
+	Finish       int
+	// Gained es grid menos llegada: positivo si ganó lugares
+	Gained int
+	// Changes cuenta los cambios de posición entre muestras consecutivas,
+	// separados en lugares ganados y perdidos en pista
+	Changes     int
+	PlacesUp    int
+	PlacesDown  int
+	Status      string
+	countsGains bool
+}
+
+// racePositionChanges calcula, con la línea de tiempo de posiciones, los
+// lugares ganados de largada a llegada y los cambios de posición de cada
+// piloto. Los que no largaron o fueron descalificados no cuentan en los
+// lugares ganados.
+func racePositionChanges(sessionKey int) ([]positionChange, error) {
+	timeline, err := positionTimeline(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]positionChange, 0, len(rows))
+	for _, r := range rows {
+		samples := timeline[r.DriverNumber]
+		pc := positionChange{
+			DriverNumber: r.DriverNumber,
+			Finish:       r.Position,
+			Status:       r.Status,
+			countsGains:  r.Status != classificationDNS && r.Status != classificationDisqualified,
+		}
+		if len(samples) > 0 {
+			pc.Grid = samples[0].Position
+		}
+		for i := 1; i < len(samples); i++ {
+			diff := samples[i-1].Position - samples[i].Position
+			if diff == 0 {
+				continue
+			}
+			pc.Changes++
+			if diff > 0 {
+				pc.PlacesUp += diff
+			} else {
+				pc.PlacesDown -= diff
+			}
+		}
+		if pc.countsGains && pc.Grid > 0 {
+			pc.Gained = pc.Grid - pc.Finish
+		}
+		changes = append(changes, pc)
+	}
+	return changes, nil
+}
+
+// mostPlacesGained devuelve el piloto que más lugares ganó en la carrera
+func mostPlacesGained(changes []positionChange) (positionChange, bool) {
+	var best positionChange
+	found := false
+	for _, pc := range changes {
+		if !pc.countsGains {
+			continue
+		}
+		if !found || pc.Gained > best.Gained {
+			best, found = pc, true
+		}
+	}
+	return best, found
+}
+
+// seasonGains acumula los movimientos de un piloto en la temporada
+type seasonGains struct {
+	DriverNumber uint
+	Races        int
+	PlacesGained int
+	Changes      int
+	PlacesUp     int
+	PlacesDown   int
+	BestGain     int
+	BestGainRace string
+}
+
+// GET /api/temporada/:year/analitica
+func getSeasonAnalytics(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+		return
+	}
+
+	var races []Session
+	if err := db.Where("year = ? AND session_name = ?", year, sessionRace).Order("date_start ASC").Find(&races).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las carreras"})
+		return
+	}
+	if len(races) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+
+	byDriver := make(map[uint]*seasonGains)
+	for _, race := range races {
+		changes, err := racePositionChanges(race.SessionKey)
+		if err != nil {
+			log.Printf("Error calculando cambios de posición de sesión %d: %v", race.SessionKey, err)
+			continue
+		}
+		for _, pc := range changes {
+			if !pc.countsGains {
+				continue
+			}
+			g, ok := byDriver[pc.DriverNumber]
+			if !ok {
+				g = &seasonGains{DriverNumber: pc.DriverNumber}
+				byDriver[pc.DriverNumber] = g
+			}
+			g.Races++
+			g.PlacesGained += pc.Gained
+			g.Changes += pc.Changes
+			g.PlacesUp += pc.PlacesUp
+			g.PlacesDown += pc.PlacesDown
+			if g.BestGainRace == "" || pc.Gained > g.BestGain {
+				g.BestGain = pc.Gained
+				g.BestGainRace = fmt.Sprintf("GP de %s", race.CountryName)
+			}
+		}
+	}
+
+	gains := make([]*seasonGains, 0, len(byDriver))
+	for _, g := range byDriver {
+		gains = append(gains, g)
+	}
+	sort.Slice(gains, func(i, j int) bool {
+		if gains[i].PlacesGained != gains[j].PlacesGained {
+			return gains[i].PlacesGained > gains[j].PlacesGained
+		}
+		return gains[i].DriverNumber < gains[j].DriverNumber
+	})
+
+	drivers := driversByNumber()
+	entries := seasonEntries(year)
+	out := make([]gin.H, 0, len(gains))
+	for i, g := range gains {
+		d := drivers[g.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		out = append(out, gin.H{
+			"position":         i + 1,
+			"driver_number":    g.DriverNumber,
+			"driver":           fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":             team,
+			"team_colour":      teamColour,
+			"races":            g.Races,
+			"places_gained":    g.PlacesGained,
+			"position_changes": g.Changes,
+			"places_up":        g.PlacesUp,
+			"places_down":      g.PlacesDown,
+			"best_gain":        g.BestGain,
+			"best_gain_race":   g.BestGainRace,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":        year,
+		"races":         len(races),
+		"places_gained": out,
+	})
+}
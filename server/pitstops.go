@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PitStop es una parada en boxes de OpenF1. PitDuration es el tiempo total
+// en el pit lane (entrada a salida), en segundos.
+type PitStop struct {
+	SessionKey   int     `json:"session_key" gorm:"uniqueIndex:idx_pit_stops_natural,priority:1"`
+	DriverNumber uint    `json:"driver_number" gorm:"uniqueIndex:idx_pit_stops_natural,priority:2"`
+	Date         string  `json:"date" gorm:"uniqueIndex:idx_pit_stops_natural,priority:3"`
+	LapNumber    int     `json:"lap_number"`
+	PitDuration  float64 `json:"pit_duration"`
+}
+
+// pitStopCounts cuenta las paradas de cada piloto en una sesión. Si OpenF1
+// no publicó paradas para la sesión, cuenta las vueltas de salida de boxes
+// después de la primera.
+func pitStopCounts(sessionKey int) map[uint]int {
+	type count struct {
+		DriverNumber uint
+		Stops        int
+	}
+	var counts []count
+	db.Model(&PitStop{}).
+		Select("driver_number, COUNT(*) AS stops").
+		Where("session_key = ?", sessionKey).
+		Group("driver_number").
+		Scan(&counts)
+	if len(counts) == 0 {
+		db.Model(&Lap{}).
+			Select("driver_number, COUNT(*) AS stops").
+			Where("session_key = ? AND is_pit_out_lap = ? AND lap_number > 1", sessionKey, true).
+			Group("driver_number").
+			Scan(&counts)
+	}
+
+	byDriver := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		byDriver[c.DriverNumber] = c.Stops
+	}
+	return byDriver
+}
+
+// GET /api/carrera/boxes/:id
+func getRacePitStops(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	var stops []PitStop
+	if err := db.Where("session_key = ?", sessionKey).Order("date ASC").Find(&stops).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las paradas en boxes"})
+		return
+	}
+
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	// Número de parada de cada piloto (en orden cronológico)
+	stopNumber := make(map[uint]int)
+	ranking := make([]gin.H, 0, len(stops))
+	type teamTotal struct {
+		Team       string
+		TeamColour string
+		Stops      int
+		Total      float64
+		Fastest    float64
+	}
+	byTeam := make(map[string]*teamTotal)
+	for _, p := range stops {
+		stopNumber[p.DriverNumber]++
+		d := drivers[p.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+
+		// Las paradas sin duración (dato faltante en OpenF1) se cuentan pero
+		// no entran en el ranking ni en los promedios
+		if p.PitDuration <= 0 {
+			continue
+		}
+		ranking = append(ranking, gin.H{
+			"driver_number": p.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"lap_number":    p.LapNumber,
+			"stop":          stopNumber[p.DriverNumber],
+			"pit_duration":  p.PitDuration,
+		})
+
+		t, ok := byTeam[team]
+		if !ok {
+			t = &teamTotal{Team: team, TeamColour: teamColour}
+			byTeam[team] = t
+		}
+		t.Stops++
+		t.Total += p.PitDuration
+		if t.Fastest == 0 || p.PitDuration < t.Fastest {
+			t.Fastest = p.PitDuration
+		}
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i]["pit_duration"].(float64) < ranking[j]["pit_duration"].(float64)
+	})
+	for i := range ranking {
+		ranking[i]["rank"] = i + 1
+	}
+
+	// Paradas por piloto, en el orden de la clasificación final
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
+		return
+	}
+	perDriver := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		perDriver = append(perDriver, gin.H{
+			"driver_number": r.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"position":      r.Position,
+			"stops":         stopNumber[r.DriverNumber],
+		})
+	}
+
+	teams := make([]*teamTotal, 0, len(byTeam))
+	for _, t := range byTeam {
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		ai, aj := teams[i].Total/float64(teams[i].Stops), teams[j].Total/float64(teams[j].Stops)
+		if ai != aj {
+			return ai < aj
+		}
+		return teams[i].Team < teams[j].Team
+	})
+	teamAverages := make([]gin.H, 0, len(teams))
+	for i, t := range teams {
+		teamAverages = append(teamAverages, gin.H{
+			"position":     i + 1,
+			"team":         t.Team,
+			"team_colour":  t.TeamColour,
+			"stops":        t.Stops,
+			"average_stop": roundMillis(t.Total / float64(t.Stops)),
+			"fastest_stop": t.Fastest,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"total_stops":        len(stops),
+		"fastest_stops":      ranking,
+		"stops_per_driver":   perDriver,
+		"team_averages":      teamAverages,
+	})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Puntos de una carrera por posición final (sistema vigente desde 2010)
+var racePointsTable = []int{25, 18, 15, 12, 10, 8, 6, 4, 2, 1}
+
+// racePoints devuelve los puntos de una carrera por posición final
+func racePoints(position int) int {
+	if position < 1 || position > len(racePointsTable) {
+		return 0
+	}
+	return racePointsTable[position-1]
+}
+
+// sprintPoints devuelve los puntos de un sprint por posición final: desde
+// 2022 puntúan los ocho primeros, en 2021 solo los tres primeros
+func sprintPoints(year, position int) int {
+	table := []int{8, 7, 6, 5, 4, 3, 2, 1}
+	if year <= 2021 {
+		table = []int{3, 2, 1}
+	}
+	if position < 1 || position > len(table) {
+		return 0
+	}
+	return table[position-1]
+}
+
+// fastestLapBonus indica si la vuelta rápida de la carrera suma un punto
+// (entre 2019 y 2024, y solo si el piloto termina entre los diez primeros)
+func fastestLapBonus(year int) bool {
+	return year >= 2019 && year <= 2024
+}
+
+// sessionResult es el resultado puntuable de un piloto en una sesión
+type sessionResult struct {
+	DriverNumber uint
+	Position     int
+	Points       int
+	// FastestLap indica si hizo la vuelta más rápida de la sesión, sume o
+	// no el punto extra
+	FastestLap bool
+}
+
+// scoreSession aplica la tabla de puntos a la clasificación final de una
+// carrera o sprint. Los pilotos no clasificados (abandonos antes del 90%
+// de la distancia, no largaron o descalificados) no suman.
+func scoreSession(s Session) ([]sessionResult, error) {
+	positions, err := sessionClassification(s.SessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var fastest Lap
+	db.Where("session_key = ? AND lap_duration > 0", s.SessionKey).
+		Order("lap_duration ASC").
+		First(&fastest)
+
+	results := make([]sessionResult, 0, len(positions))
+	for _, p := range positions {
+		r := sessionResult{
+			DriverNumber: p.DriverNumber,
+			Position:     p.Position,
+			FastestLap:   fastest.DriverNumber == p.DriverNumber,
+		}
+		switch {
+		case !p.Classified:
+			r.Points = 0
+		case s.IsSprint:
+			r.Points = sprintPoints(s.Year, p.Position)
+		default:
+			r.Points = racePoints(p.Position)
+			// La vuelta rápida solo puntúa en las carreras
+			if r.FastestLap && fastestLapBonus(s.Year) && p.Position <= 10 {
+				r.Points++
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// scoredSessions devuelve las carreras y sprints de una temporada en orden
+// cronológico
+func scoredSessions(year int) ([]Session, error) {
+	var sessions []Session
+	err := db.Where("year = ? AND session_name IN ?", year, []string{sessionRace, sessionSprint}).
+		Order("date_start ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// driverStanding acumula los puntos de un piloto en el campeonato
+type driverStanding struct {
+	DriverNumber uint
+	Points       int
+	Wins         int
+	Podiums      int
+	SprintPoints int
+	SprintWins   int
+	// finishes cuenta cuántas veces terminó en cada posición de carrera,
+	// para desempatar por countback
+	finishes map[int]int
+}
+
+// standingsTable es el campeonato de pilotos en construcción
+type standingsTable struct {
+	byDriver map[uint]*driverStanding
+}
+
+func newStandingsTable() *standingsTable {
+	return &standingsTable{byDriver: make(map[uint]*driverStanding)}
+}
+
+// add suma los resultados de una sesión al campeonato
+func (t *standingsTable) add(s Session, results []sessionResult) {
+	for _, r := range results {
+		d, ok := t.byDriver[r.DriverNumber]
+		if !ok {
+			d = &driverStanding{DriverNumber: r.DriverNumber, finishes: make(map[int]int)}
+			t.byDriver[r.DriverNumber] = d
+		}
+
+		d.Points += r.Points
+		if s.IsSprint {
+			d.SprintPoints += r.Points
+			if r.Position == 1 {
+				d.SprintWins++
+			}
+			continue
+		}
+
+		d.finishes[r.Position]++
+		if r.Position == 1 {
+			d.Wins++
+		}
+		if r.Position <= 3 {
+			d.Podiums++
+		}
+	}
+}
+
+// sorted devuelve la clasificación ordenada por puntos. Los empates se
+// resuelven por countback: más victorias, luego más segundos puestos, etc.
+func (t *standingsTable) sorted() []*driverStanding {
+	out := make([]*driverStanding, 0, len(t.byDriver))
+	maxPosition := 0
+	for _, d := range t.byDriver {
+		out = append(out, d)
+		for pos := range d.finishes {
+			if pos > maxPosition {
+				maxPosition = pos
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		for pos := 1; pos <= maxPosition; pos++ {
+			if a.finishes[pos] != b.finishes[pos] {
+				return a.finishes[pos] > b.finishes[pos]
+			}
+		}
+		return a.DriverNumber < b.DriverNumber
+	})
+	return out
+}
+
+// driverStandings calcula el campeonato de pilotos de una temporada
+func driverStandings(year int) ([]*driverStanding, int, error) {
+	sessions, err := scoredSessions(year)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	table := newStandingsTable()
+	rounds := 0
+	for _, s := range sessions {
+		results, err := scoreSession(s)
+		if err != nil {
+			log.Printf("Error puntuando sesión %d: %v", s.SessionKey, err)
+			continue
+		}
+		if !s.IsSprint && len(results) > 0 {
+			rounds++
+		}
+		table.add(s, results)
+	}
+	return table.sorted(), rounds, nil
+}
+
+// GET /api/temporada/:year/clasificacion
+func getDriverStandings(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+		return
+	}
+
+	standings, rounds, err := driverStandings(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la clasificación"})
+		return
+	}
+	if rounds == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+
+	// Equipo con que cada piloto terminó la temporada
+	entries := seasonEntries(year)
+
+	out := make([]gin.H, 0, len(standings))
+	for i, d := range standings {
+		var driver Driver
+		db.First(&driver, "driver_number = ?", d.DriverNumber)
+		team, teamColour := teamOf(entries, driver)
+		out = append(out, gin.H{
+			"position":      i + 1,
+			"driver_number": d.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"country":       driver.CountryCode,
+			"points":        d.Points,
+			"wins":          d.Wins,
+			"podiums":       d.Podiums,
+			"sprint_points": d.SprintPoints,
+			"sprint_wins":   d.SprintWins,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":    year,
+		"rounds":    rounds,
+		"standings": out,
+	})
+}
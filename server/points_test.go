@@ -0,0 +1,171 @@
+package main
+
+import "testing"
+
+func TestSprintPoints(t *testing.T) {
+	tests := []struct {
+		year, position, want int
+	}{
+		{2021, 1, 3},
+		{2021, 3, 1},
+		{2021, 4, 0},
+		{2022, 1, 8},
+		{2024, 8, 1},
+		{2024, 9, 0},
+		{2024, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := sprintPoints(tt.year, tt.position); got != tt.want {
+			t.Errorf("sprintPoints(%d, %d) = %d, want %d", tt.year, tt.position, got, tt.want)
+		}
+	}
+}
+
+func TestScoreSession(t *testing.T) {
+	useTestDB(t)
+
+	const key = 9574
+	rows := []Classification{
+		{SessionKey: key, DriverNumber: 1, Position: 1, Status: classificationFinished, Classified: true},
+		{SessionKey: key, DriverNumber: 4, Position: 2, Status: classificationFinished, Classified: true},
+		{SessionKey: key, DriverNumber: 81, Position: 3, Status: classificationFinished, Classified: true},
+		{SessionKey: key, DriverNumber: 16, Position: 4, Status: classificationDNF, Classified: true},
+		{SessionKey: key, DriverNumber: 55, Position: 5, Status: classificationDNF},
+		{SessionKey: key, DriverNumber: 63, Position: 6, Status: classificationDisqualified},
+	}
+	// El tercero hace la vuelta más rápida
+	laps := []Lap{
+		{SessionKey: key, DriverNumber: 1, LapNumber: 2, LapDuration: 90.5},
+		{SessionKey: key, DriverNumber: 81, LapNumber: 2, LapDuration: 89.9},
+		{SessionKey: key, DriverNumber: 63, LapNumber: 3, LapDuration: 90.1},
+	}
+	if err := db.Create(rows).Error; err != nil {
+		t.Fatalf("insertando clasificación: %v", err)
+	}
+	if err := db.Create(laps).Error; err != nil {
+		t.Fatalf("insertando vueltas: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		session Session
+		want    map[uint]int
+	}{
+		{
+			name:    "carrera con punto por vuelta rápida",
+			session: Session{SessionKey: key, Year: 2024},
+			want:    map[uint]int{1: 25, 4: 18, 81: 16, 16: 12, 55: 0, 63: 0},
+		},
+		{
+			name:    "carrera sin punto por vuelta rápida",
+			session: Session{SessionKey: key, Year: 2025},
+			want:    map[uint]int{1: 25, 4: 18, 81: 15, 16: 12, 55: 0, 63: 0},
+		},
+		{
+			name:    "sprint desde 2022",
+			session: Session{SessionKey: key, Year: 2024, IsSprint: true},
+			want:    map[uint]int{1: 8, 4: 7, 81: 6, 16: 5, 55: 0, 63: 0},
+		},
+		{
+			name:    "sprint de 2021",
+			session: Session{SessionKey: key, Year: 2021, IsSprint: true},
+			want:    map[uint]int{1: 3, 4: 2, 81: 1, 16: 0, 55: 0, 63: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := scoreSession(tt.session)
+			if err != nil {
+				t.Fatalf("scoreSession: %v", err)
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d resultados, want %d", len(results), len(tt.want))
+			}
+			for _, r := range results {
+				if r.Points != tt.want[r.DriverNumber] {
+					t.Errorf("piloto %d: %d puntos, want %d", r.DriverNumber, r.Points, tt.want[r.DriverNumber])
+				}
+				if r.FastestLap != (r.DriverNumber == 81) {
+					t.Errorf("piloto %d: FastestLap = %v", r.DriverNumber, r.FastestLap)
+				}
+			}
+		})
+	}
+}
+
+func TestStandingsCountback(t *testing.T) {
+	race := Session{}
+	sprint := Session{IsSprint: true}
+	result := func(driver uint, position, points int) sessionResult {
+		return sessionResult{DriverNumber: driver, Position: position, Points: points}
+	}
+
+	tests := []struct {
+		name     string
+		sessions []Session
+		results  [][]sessionResult
+		want     []uint
+	}{
+		{
+			name:     "más puntos primero",
+			sessions: []Session{race},
+			results:  [][]sessionResult{{result(4, 1, 25), result(44, 2, 18)}},
+			want:     []uint{4, 44},
+		},
+		{
+			name:     "empate: más victorias",
+			sessions: []Session{race, race},
+			results: [][]sessionResult{
+				{result(44, 1, 25), result(4, 2, 18)},
+				{result(4, 6, 8), result(44, 10, 1)},
+			},
+			want: []uint{44, 4},
+		},
+		{
+			name:     "empate sin victorias: más segundos puestos",
+			sessions: []Session{race, race},
+			results: [][]sessionResult{
+				{result(44, 2, 18), result(4, 4, 12)},
+				{result(4, 6, 8), result(44, 9, 2)},
+			},
+			want: []uint{44, 4},
+		},
+		{
+			name:     "mismos puestos: número de piloto",
+			sessions: []Session{race, race},
+			results: [][]sessionResult{
+				{result(44, 2, 18), result(4, 3, 15)},
+				{result(4, 2, 18), result(44, 3, 15)},
+			},
+			want: []uint{4, 44},
+		},
+		{
+			name:     "los sprints no cuentan para el countback",
+			sessions: []Session{race, sprint, race, sprint},
+			results: [][]sessionResult{
+				{result(44, 2, 18)},
+				{result(44, 1, 8)},
+				{result(4, 1, 25)},
+				{result(4, 8, 1)},
+			},
+			want: []uint{4, 44},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newStandingsTable()
+			for i, s := range tt.sessions {
+				table.add(s, tt.results[i])
+			}
+			got := table.sorted()
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d pilotos, want %d", len(got), len(tt.want))
+			}
+			for i, d := range got {
+				if d.DriverNumber != tt.want[i] {
+					t.Errorf("puesto %d: piloto %d, want %d", i+1, d.DriverNumber, tt.want[i])
+				}
+			}
+		})
+	}
+}
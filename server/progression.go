@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seasonRound agrupa las sesiones puntuables de un fin de semana (sprint y
+// carrera comparten meeting_key)
+type seasonRound struct {
+	MeetingKey int
+	Sessions   []Session
+}
+
+// groupRounds agrupa sesiones ordenadas por fecha en fechas del calendario
+func groupRounds(sessions []Session) []seasonRound {
+	var rounds []seasonRound
+	for _, s := range sessions {
+		key := s.MeetingKey
+		if key == 0 {
+			// Bases antiguas sin meeting_key: cada sesión es su propia fecha
+			key = -s.SessionKey
+		}
+		if n := len(rounds); n > 0 && rounds[n-1].MeetingKey == key {
+			rounds[n-1].Sessions = append(rounds[n-1].Sessions, s)
+			continue
+		}
+		rounds = append(rounds, seasonRound{MeetingKey: key, Sessions: []Session{s}})
+	}
+	return rounds
+}
+
+// hasSprint indica si la fecha tuvo sprint
+func (r seasonRound) hasSprint() bool {
+	for _, s := range r.Sessions {
+		if s.IsSprint {
+			return true
+		}
+	}
+	return false
+}
+
+// race devuelve la carrera principal de la fecha (o la última sesión si
+// todavía solo se corrió el sprint)
+func (r seasonRound) race() Session {
+	for _, s := range r.Sessions {
+		if !s.IsSprint {
+			return s
+		}
+	}
+	return r.Sessions[len(r.Sessions)-1]
+}
+
+// progressionSeries es la evolución de un piloto o equipo: puntos
+// acumulados y posición en el campeonato después de cada fecha (0 si
+// todavía no había sumado resultados)
+type progressionSeries struct {
+	Points    []int
+	Positions []int
+}
+
+func (p *progressionSeries) record(round, points, position int) {
+	for len(p.Points) < round {
+		p.Points = append(p.Points, 0)
+		p.Positions = append(p.Positions, 0)
+	}
+	p.Points = append(p.Points, points)
+	p.Positions = append(p.Positions, position)
+}
+
+// GET /api/temporada/:year/progresion
+func getStandingsProgression(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+		return
+	}
+
+	sessions, err := scoredSessions(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las sesiones"})
+		return
+	}
+
+	drivers := driversByNumber()
+	driverTable := newStandingsTable()
+	teamTable := newConstructorTable()
+	driverSeries := make(map[uint]*progressionSeries)
+	teamSeries := make(map[string]*progressionSeries)
+	rounds := []gin.H{}
+
+	for _, round := range groupRounds(sessions) {
+		scored := false
+		for _, s := range round.Sessions {
+			results, err := scoreSession(s)
+			if err != nil {
+				log.Printf("Error puntuando sesión %d: %v", s.SessionKey, err)
+				continue
+			}
+			if len(results) == 0 {
+				continue
+			}
+			scored = true
+			driverTable.add(s, results)
+			teamTable.add(s, results, sessionTeams(s.SessionKey, drivers))
+		}
+		if !scored {
+			continue
+		}
+
+		// Foto del campeonato al terminar la fecha
+		n := len(rounds)
+		for i, d := range driverTable.sorted() {
+			series, ok := driverSeries[d.DriverNumber]
+			if !ok {
+				series = &progressionSeries{}
+				driverSeries[d.DriverNumber] = series
+			}
+			series.record(n, d.Points, i+1)
+		}
+		for i, t := range teamTable.sorted() {
+			series, ok := teamSeries[t.Team]
+			if !ok {
+				series = &progressionSeries{}
+				teamSeries[t.Team] = series
+			}
+			series.record(n, t.Points, i+1)
+		}
+
+		race := round.race()
+		rounds = append(rounds, gin.H{
+			"round":              n + 1,
+			"session_key":        race.SessionKey,
+			"race":               fmt.Sprintf("GP de %s", race.CountryName),
+			"circuit_short_name": race.CircuitShortName,
+			"date_start":         race.DateStart,
+			"sprint":             round.hasSprint(),
+		})
+	}
+
+	if len(rounds) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+
+	// Las series se listan en el orden del campeonato final
+	entries := seasonEntries(year)
+	driversOut := []gin.H{}
+	for _, d := range driverTable.sorted() {
+		driver := drivers[d.DriverNumber]
+		team, teamColour := teamOf(entries, driver)
+		series := driverSeries[d.DriverNumber]
+		driversOut = append(driversOut, gin.H{
+			"driver_number": d.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"points":        series.Points,
+			"positions":     series.Positions,
+		})
+	}
+
+	teamsOut := []gin.H{}
+	for _, t := range teamTable.sorted() {
+		series := teamSeries[t.Team]
+		teamsOut = append(teamsOut, gin.H{
+			"team":        t.Team,
+			"team_colour": t.TeamColour,
+			"points":      series.Points,
+			"positions":   series.Positions,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":  year,
+		"rounds":  rounds,
+		"drivers": driversOut,
+		"teams":   teamsOut,
+	})
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RaceControl es un mensaje de dirección de carrera de OpenF1: banderas,
+// safety car, penalizaciones, abandonos y descalificaciones. Su clave
+// natural es la sesión, la hora y el texto del mensaje.
+type RaceControl struct {
+	SessionKey   int    `json:"session_key" gorm:"uniqueIndex:idx_race_controls_natural,priority:1"`
+	Date         string `json:"date" gorm:"uniqueIndex:idx_race_controls_natural,priority:2"`
+	Message      string `json:"message" gorm:"uniqueIndex:idx_race_controls_natural,priority:3"`
+	Category     string `json:"category"`
+	Flag         string `json:"flag"`
+	Scope        string `json:"scope"`
+	Sector       int    `json:"sector"`
+	DriverNumber uint   `json:"driver_number" gorm:"index"`
+	LapNumber    int    `json:"lap_number"`
+}
+
+// Tipos de neutralización de una carrera
+const (
+	neutralSafetyCar        = "sc"
+	neutralVirtualSafetyCar = "vsc"
+	neutralRedFlag          = "red_flag"
+)
+
+// trackPeriod es un tramo neutralizado de la carrera. End queda en cero si
+// la sesión terminó sin volver a bandera verde.
+type trackPeriod struct {
+	Kind      string
+	Start     time.Time
+	End       time.Time
+	StartDate string
+	EndDate   string
+	StartLap  int
+	EndLap    int
+}
+
+// overlaps indica si el intervalo [start, end] cae, aunque sea en parte,
+// dentro del período
+func (p trackPeriod) overlaps(start, end time.Time) bool {
+	return !end.Before(p.Start) && (p.End.IsZero() || start.Before(p.End))
+}
+
+// sessionRaceControl devuelve los mensajes de dirección de carrera de una
+// sesión en orden cronológico
+func sessionRaceControl(sessionKey int) ([]RaceControl, error) {
+	var messages []RaceControl
+	err := db.Where("session_key = ?", sessionKey).Order("date ASC").Find(&messages).Error
+	return messages, err
+}
+
+// neutralizedPeriods arma los períodos de safety car, virtual safety car y
+// bandera roja a partir de los mensajes (en orden cronológico). El virtual
+// safety car termina con su mensaje de fin; el safety car y la bandera
+// roja, con la siguiente bandera verde de pista o la bandera a cuadros.
+func neutralizedPeriods(messages []RaceControl) []trackPeriod {
+	var periods []trackPeriod
+	var open *trackPeriod
+	for _, m := range messages {
+		at, ok := parseOpenF1Time(m.Date)
+		if !ok {
+			continue
+		}
+		text := strings.ToUpper(m.Message)
+
+		kind := ""
+		switch {
+		case m.Flag == "RED":
+			kind = neutralRedFlag
+		case strings.Contains(text, "VIRTUAL SAFETY CAR DEPLOYED"):
+			kind = neutralVirtualSafetyCar
+		case strings.Contains(text, "SAFETY CAR DEPLOYED"):
+			kind = neutralSafetyCar
+		}
+		if kind != "" {
+			if open != nil && open.Kind == kind {
+				continue
+			}
+			// Una bandera roja durante un safety car cierra el safety car
+			if open != nil {
+				open.End, open.EndDate, open.EndLap = at, m.Date, m.LapNumber
+				periods = append(periods, *open)
+			}
+			open = &trackPeriod{Kind: kind, Start: at, StartDate: m.Date, StartLap: m.LapNumber}
+			continue
+		}
+		if open == nil {
+			continue
+		}
+
+		ends := m.Flag == "CHEQUERED" || (m.Flag == "GREEN" && m.Scope == "Track")
+		if open.Kind == neutralVirtualSafetyCar {
+			ends = ends || strings.Contains(text, "VIRTUAL SAFETY CAR ENDING")
+		}
+		if ends {
+			open.End, open.EndDate, open.EndLap = at, m.Date, m.LapNumber
+			periods = append(periods, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		periods = append(periods, *open)
+	}
+	return periods
+}
+
+// lapNeutralization devuelve el tipo de neutralización que afectó una
+// vuelta que empezó en start y duró duration segundos ("" si ninguna)
+func lapNeutralization(periods []trackPeriod, start time.Time, duration float64) string {
+	end := start.Add(time.Duration(duration * float64(time.Second)))
+	for _, p := range periods {
+		if p.overlaps(start, end) {
+			return p.Kind
+		}
+	}
+	return ""
+}
+
+// isPenaltyMessage reconoce las penalizaciones a un piloto
+func isPenaltyMessage(m RaceControl) bool {
+	return m.DriverNumber > 0 && strings.Contains(strings.ToUpper(m.Message), "PENALTY")
+}
+
+// GET /api/carrera/direccion/:id
+func getRaceControl(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	messages, err := sessionRaceControl(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los mensajes de dirección de carrera"})
+		return
+	}
+
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	timeline := make([]gin.H, 0, len(messages))
+	flags := make([]gin.H, 0)
+	penalties := make([]gin.H, 0)
+	for _, m := range messages {
+		timeline = append(timeline, gin.H{
+			"date":          m.Date,
+			"lap_number":    m.LapNumber,
+			"category":      m.Category,
+			"flag":          m.Flag,
+			"scope":         m.Scope,
+			"sector":        m.Sector,
+			"driver_number": m.DriverNumber,
+			"message":       m.Message,
+		})
+
+		if m.Flag != "" {
+			flags = append(flags, gin.H{
+				"date":       m.Date,
+				"lap_number": m.LapNumber,
+				"flag":       m.Flag,
+				"scope":      m.Scope,
+				"sector":     m.Sector,
+				"message":    m.Message,
+			})
+		}
+
+		if isPenaltyMessage(m) {
+			d := drivers[m.DriverNumber]
+			team, teamColour := teamOf(entries, d)
+			penalties = append(penalties, gin.H{
+				"date":          m.Date,
+				"lap_number":    m.LapNumber,
+				"driver_number": m.DriverNumber,
+				"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+				"team":          team,
+				"team_colour":   teamColour,
+				"message":       m.Message,
+			})
+		}
+	}
+
+	periods := neutralizedPeriods(messages)
+	neutralized := make([]gin.H, 0, len(periods))
+	for _, p := range periods {
+		period := gin.H{
+			"type":      p.Kind,
+			"start":     p.StartDate,
+			"lap_start": p.StartLap,
+			"end":       nil,
+			"lap_end":   nil,
+			"duration":  nil,
+		}
+		if !p.End.IsZero() {
+			period["end"] = p.EndDate
+			period["lap_end"] = p.EndLap
+			period["duration"] = roundMillis(p.End.Sub(p.Start).Seconds())
+		}
+		neutralized = append(neutralized, period)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"messages":           timeline,
+		"flags":              flags,
+		"neutralizations":    neutralized,
+		"penalties":          penalties,
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestNeutralizedPeriods(t *testing.T) {
+	msg := func(at float64, lap int, flag, scope, text string) RaceControl {
+		return RaceControl{Date: openF1Time(at), LapNumber: lap, Flag: flag, Scope: scope, Message: text}
+	}
+
+	tests := []struct {
+		name     string
+		messages []RaceControl
+		want     []trackPeriod
+	}{
+		{
+			name: "safety car hasta la bandera verde de pista",
+			messages: []RaceControl{
+				msg(100, 2, "", "", "SAFETY CAR DEPLOYED"),
+				msg(150, 3, "GREEN", "Sector", "GREEN LIGHT IN SECTOR 2"),
+				msg(400, 5, "GREEN", "Track", "TRACK CLEAR"),
+			},
+			want: []trackPeriod{
+				{Kind: neutralSafetyCar, StartDate: openF1Time(100), EndDate: openF1Time(400), StartLap: 2, EndLap: 5},
+			},
+		},
+		{
+			name: "virtual safety car hasta su mensaje de fin",
+			messages: []RaceControl{
+				msg(100, 2, "", "", "VIRTUAL SAFETY CAR DEPLOYED"),
+				msg(160, 3, "", "", "VIRTUAL SAFETY CAR ENDING"),
+				msg(400, 5, "GREEN", "Track", "TRACK CLEAR"),
+			},
+			want: []trackPeriod{
+				{Kind: neutralVirtualSafetyCar, StartDate: openF1Time(100), EndDate: openF1Time(160), StartLap: 2, EndLap: 3},
+			},
+		},
+		{
+			name: "la bandera roja cierra el safety car",
+			messages: []RaceControl{
+				msg(100, 2, "", "", "SAFETY CAR DEPLOYED"),
+				msg(100.5, 2, "", "", "SAFETY CAR DEPLOYED"),
+				msg(200, 3, "RED", "Track", "RED FLAG"),
+				msg(1200, 3, "GREEN", "Track", "TRACK CLEAR"),
+			},
+			want: []trackPeriod{
+				{Kind: neutralSafetyCar, StartDate: openF1Time(100), EndDate: openF1Time(200), StartLap: 2, EndLap: 3},
+				{Kind: neutralRedFlag, StartDate: openF1Time(200), EndDate: openF1Time(1200), StartLap: 3, EndLap: 3},
+			},
+		},
+		{
+			name: "safety car abierto hasta la bandera a cuadros",
+			messages: []RaceControl{
+				msg(100, 9, "", "", "SAFETY CAR DEPLOYED"),
+				msg(300, 10, "CHEQUERED", "Track", "CHEQUERED FLAG"),
+			},
+			want: []trackPeriod{
+				{Kind: neutralSafetyCar, StartDate: openF1Time(100), EndDate: openF1Time(300), StartLap: 9, EndLap: 10},
+			},
+		},
+		{
+			name: "sin mensaje de fin queda abierto",
+			messages: []RaceControl{
+				{Date: "sin fecha", Message: "SAFETY CAR DEPLOYED"},
+				msg(100, 9, "", "", "SAFETY CAR DEPLOYED"),
+			},
+			want: []trackPeriod{
+				{Kind: neutralSafetyCar, StartDate: openF1Time(100), StartLap: 9},
+			},
+		},
+		{
+			name: "sin neutralizaciones",
+			messages: []RaceControl{
+				msg(0, 1, "GREEN", "Track", "GREEN LIGHT - PIT EXIT OPEN"),
+				msg(50, 1, "YELLOW", "Sector", "YELLOW IN TRACK SECTOR 4"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := neutralizedPeriods(tt.messages)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d períodos, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Kind != w.Kind || g.StartDate != w.StartDate || g.EndDate != w.EndDate || g.StartLap != w.StartLap || g.EndLap != w.EndLap {
+					t.Errorf("período %d:\n got  %s %s-%s vueltas %d-%d\n want %s %s-%s vueltas %d-%d", i,
+						g.Kind, g.StartDate, g.EndDate, g.StartLap, g.EndLap,
+						w.Kind, w.StartDate, w.EndDate, w.StartLap, w.EndLap)
+				}
+				if g.End.IsZero() != (w.EndDate == "") {
+					t.Errorf("período %d: End = %v", i, g.End)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import "gorm.io/gorm"
+
+// Nombres de sesión de OpenF1 que se ingieren
+const (
+	sessionRace       = "Race"
+	sessionQualifying = "Qualifying"
+	sessionSprint     = "Sprint"
+)
+
+// ingestedSessionNames son las sesiones de cada fin de semana que se
+// descargan por temporada
+var ingestedSessionNames = []string{sessionRace, sessionQualifying, sessionSprint}
+
+// Filtros de /api/carrera?type=
+const (
+	raceTypeRace   = "race"
+	raceTypeSprint = "sprint"
+	raceTypeAll    = "all"
+)
+
+// raceSessionNames traduce el filtro ?type= a los nombres de sesión que
+// cuentan como carrera (por defecto solo las carreras del domingo)
+func raceSessionNames(raceType string) ([]string, bool) {
+	switch raceType {
+	case "", raceTypeRace:
+		return []string{sessionRace}, true
+	case raceTypeSprint:
+		return []string{sessionSprint}, true
+	case raceTypeAll:
+		return []string{sessionRace, sessionSprint}, true
+	}
+	return nil, false
+}
+
+// startingPositions devuelve la primera muestra de posición de cada piloto
+// en una carrera, que en OpenF1 corresponde a la grilla de partida
+func startingPositions(tx *gorm.DB, sessionKey int) (map[uint]int, error) {
+	var positions []Position
+	err := tx.Raw(`
+        SELECT p.*
+        FROM positions p
+        JOIN (
+            SELECT driver_number, MIN(date) AS date
+            FROM positions
+            WHERE session_key = ?
+            GROUP BY driver_number
+        ) first ON first.driver_number = p.driver_number AND first.date = p.date
+        WHERE p.session_key = ?
+    `, sessionKey, sessionKey).Scan(&positions).Error
+
+	grid := make(map[uint]int, len(positions))
+	for _, p := range positions {
+		grid[p.DriverNumber] = p.Position
+	}
+	return grid, err
+}
+
+// qualifyingFor busca la clasificación del mismo fin de semana que la
+// carrera. Los sprints no tienen: su grilla sale del sprint qualifying.
+func qualifyingFor(race Session) (Session, bool) {
+	var quali Session
+	if race.MeetingKey == 0 || race.SessionName != sessionRace {
+		return quali, false
+	}
+	err := db.Where("meeting_key = ? AND session_name = ?", race.MeetingKey, sessionQualifying).
+		First(&quali).Error
+	return quali, err == nil
+}
+
+// qualifyingPositions devuelve la posición final de cada piloto en la
+// clasificación del fin de semana de la carrera (vacío si no hay)
+func qualifyingPositions(race Session) (Session, map[uint]int) {
+	byDriver := make(map[uint]int)
+
+	quali, ok := qualifyingFor(race)
+	if !ok {
+		return quali, byDriver
+	}
+
+	positions, err := sessionClassification(quali.SessionKey)
+	if err != nil {
+		return quali, byDriver
+	}
+	for _, p := range positions {
+		byDriver[p.DriverNumber] = p.Position
+	}
+	return quali, byDriver
+}
+
+// finalWinnersSQL cuenta, por piloto, las sesiones de un tipo y temporada
+// que terminó en primer lugar según la clasificación final
+const finalWinnersSQL = `
+        SELECT c.driver_number, COUNT(*) AS count
+        FROM classifications c
+        JOIN sessions s ON s.session_key = c.session_key
+        WHERE c.position = 1 AND s.session_name = ? AND s.year = ?
+        GROUP BY c.driver_number
+        ORDER BY count DESC
+        LIMIT 3
+    `
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// syncScheduler repite la sincronización incremental cada cierto intervalo
+// para que las carreras nuevas aparezcan sin reiniciar el servidor.
+type syncScheduler struct {
+	interval time.Duration
+
+	mu          sync.Mutex
+	running     bool
+	lastRun     *time.Time
+	lastElapsed time.Duration
+	lastSummary *syncSummary
+	lastError   string
+	lastJobID   string
+	nextRun     *time.Time
+}
+
+// Sincronizador global (nil hasta que arranca el servidor)
+var scheduler *syncScheduler
+
+func newSyncScheduler(interval time.Duration) *syncScheduler {
+	return &syncScheduler{interval: interval}
+}
+
+func (s *syncScheduler) start() {
+	if s.interval <= 0 {
+		log.Println("⏸️ Sincronización en segundo plano desactivada")
+		return
+	}
+
+	log.Printf("⏰ Sincronización en segundo plano cada %s", s.interval)
+	ticker := time.NewTicker(s.interval)
+	s.setNextRun(time.Now().Add(s.interval))
+
+	go func() {
+		for range ticker.C {
+			s.run()
+			s.setNextRun(time.Now().Add(s.interval))
+		}
+	}()
+}
+
+func (s *syncScheduler) setNextRun(t time.Time) {
+	s.mu.Lock()
+	s.nextRun = &t
+	s.mu.Unlock()
+}
+
+// run ejecuta una sincronización como job programado, salvo que ya haya
+// una sincronización completa en curso (de este u otro origen)
+func (s *syncScheduler) run() {
+	job, err := jobs.create(jobScheduled, false)
+	if err != nil {
+		log.Printf("⚠️ %v (job %s), se omite esta ejecución", err, job.id)
+		return
+	}
+
+	s.mu.Lock()
+	s.running = true
+	s.lastJobID = job.id
+	s.mu.Unlock()
+
+	started := time.Now()
+	log.Printf("⏰ Iniciando sincronización programada (job %s)...", job.id)
+	summary, err := runSync(job, false)
+	err = failedSessionsError(summary, err)
+	jobs.finish(job, summary, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.lastRun = &started
+	s.lastElapsed = time.Since(started)
+	s.lastSummary = &summary
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+		log.Printf("❌ Sincronización programada falló: %v", err)
+		return
+	}
+	log.Printf("✅ Sincronización programada terminada en %s: %d carreras nuevas, %d sesiones completas, %d fallidas",
+		s.lastElapsed.Round(time.Millisecond), summary.NewSessions, summary.Completed, summary.Failed)
+}
+
+// status devuelve el estado del sincronizador para la API
+func (s *syncScheduler) status() gin.H {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return gin.H{
+		"enabled":       s.interval > 0,
+		"interval":      s.interval.String(),
+		"running":       s.running,
+		"last_run":      s.lastRun,
+		"last_duration": s.lastElapsed.String(),
+		"last_result":   s.lastSummary,
+		"last_error":    s.lastError,
+		"last_job_id":   s.lastJobID,
+		"next_run":      s.nextRun,
+	}
+}
+
+// GET /api/admin/sync/schedule
+func getSyncSchedule(c *gin.Context) {
+	if scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sincronizador no iniciado"})
+		return
+	}
+	c.JSON(http.StatusOK, scheduler.status())
+}
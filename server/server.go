@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Modelos
@@ -24,40 +26,60 @@ type Driver struct {
 	LastName     string `json:"last_name"`
 	NameAcronym  string `json:"name_acronym"`
 	TeamName     string `json:"team_name"`
+	TeamColour   string `json:"team_colour"`
 	CountryCode  string `json:"country_code"`
 }
 type Session struct {
 	SessionKey       int    `json:"session_key" gorm:"primaryKey"`
 	SessionName      string `json:"session_name"`
 	SessionType      string `json:"session_type"`
+	MeetingKey       int    `json:"meeting_key" gorm:"index"`
+	IsSprint         bool   `json:"is_sprint" gorm:"index"`
 	Location         string `json:"location"`
 	CountryName      string `json:"country_name"`
 	Year             int    `json:"year"`
 	CircuitShortName string `json:"circuit_short_name"`
 	DateStart        string `json:"date_start"`
+	DateEnd          string `json:"date_end"`
 }
+
+// DriverSession registra con qué equipo (y color) corrió cada piloto en
+// cada sesión, tal como viene en la lista de inscritos de OpenF1
+type DriverSession struct {
+	DriverNumber uint   `json:"driver_number" gorm:"primaryKey;autoIncrement:false"`
+	SessionKey   int    `json:"session_key" gorm:"primaryKey;autoIncrement:false"`
+	TeamName     string `json:"team_name"`
+	TeamColour   string `json:"team_colour"`
+}
+
+// Position y Lap no tienen clave primaria propia: su clave natural es un
+// índice único, así reingerir una sesión actualiza en vez de duplicar.
 type Position struct {
-	DriverNumber uint   `json:"driver_number"`
-	SessionKey   int    `json:"session_key"`
+	DriverNumber uint   `json:"driver_number" gorm:"uniqueIndex:idx_positions_natural,priority:2"`
+	SessionKey   int    `json:"session_key" gorm:"uniqueIndex:idx_positions_natural,priority:1"`
 	Position     int    `json:"position"`
-	Date         string `json:"date"`
+	Date         string `json:"date" gorm:"uniqueIndex:idx_positions_natural,priority:3"`
 }
 
 type Lap struct {
-	DriverNumber    uint    `json:"driver_number"`
-	SessionKey      int     `json:"session_key"`
-	LapNumber       int     `json:"lap_number"`
+	DriverNumber    uint    `json:"driver_number" gorm:"uniqueIndex:idx_laps_natural,priority:2"`
+	SessionKey      int     `json:"session_key" gorm:"uniqueIndex:idx_laps_natural,priority:1"`
+	LapNumber       int     `json:"lap_number" gorm:"uniqueIndex:idx_laps_natural,priority:3"`
 	LapDuration     float64 `json:"lap_duration"`
 	DurationSector1 float64 `json:"duration_sector_1"`
 	DurationSector2 float64 `json:"duration_sector_2"`
 	DurationSector3 float64 `json:"duration_sector_3"`
 	StSpeed         float64 `json:"st_speed"`
+	IsPitOutLap     bool    `json:"is_pit_out_lap"`
 	DateStart       string  `json:"date_start"`
 }
 
 // Base de datos global
 var db *gorm.DB
 
+// Tablas que crea AutoMigrate
+var models = []interface{}{&Driver{}, &Session{}, &DriverSession{}, &Position{}, &Lap{}, &RaceControl{}, &Stint{}, &PitStop{}, &Weather{}, &Interval{}, &CarData{}, &Classification{}, &SyncState{}}
+
 // Inicializar DB
 func initDatabase() {
 	var err error
@@ -66,8 +88,12 @@ func initDatabase() {
 		log.Fatal("Error conectando a proxy.db:", err)
 	}
 
-	// Migrar tabla Driver por ahora
-	err = db.AutoMigrate(&Driver{}, &Session{}, &Position{}, &Lap{})
+	// Migraciones de datos que deben correr antes de crear índices nuevos
+	if err := runMigrations(); err != nil {
+		log.Fatal("Error aplicando migraciones:", err)
+	}
+
+	err = db.AutoMigrate(models...)
 	if err != nil {
 		log.Fatal("Error migrando base de datos:", err)
 	}
@@ -104,102 +130,148 @@ func getDrivers(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// autoPopulateDriversIfNeeded arma la lista de pilotos uniendo la lista de
+// inscritos de cada sesión ingerida. Solo se consultan las sesiones cuya
+// lista todavía no está en driver_sessions, así que los sustitutos de
+// mitad de temporada aparecen solos al ingerir su primera carrera.
 func autoPopulateDriversIfNeeded() error {
-	var count int64
-	db.Model(&Driver{}).Count(&count)
+	var sessions []Session
+	if err := db.
+		Where("session_key NOT IN (?)", db.Model(&DriverSession{}).Distinct("session_key")).
+		Order("date_start ASC").
+		Find(&sessions).Error; err != nil {
+		return fmt.Errorf("error obteniendo sesiones sin pilotos: %w", err)
+	}
 
-	if count > 0 {
-		log.Println("✔️ Tabla de pilotos ya tiene datos.")
+	if len(sessions) == 0 {
+		log.Println("✔️ Tabla de pilotos ya tiene datos de todas las sesiones.")
 		return nil
 	}
 
-	log.Println("📥 Poblando tabla de pilotos desde OpenF1...")
+	log.Printf("📥 Poblando pilotos de %d sesiones...", len(sessions))
 
-	// Pilotos principales de session_key 9574
-	mainDriverNums := []uint{1, 2, 3, 4, 10, 11, 14, 16, 18, 20, 22, 23, 24, 27, 31, 44, 55, 63, 77, 81}
-	// Pilotos adicionales de session_key 9636
-	extraDriverNums := []uint{30, 50, 43}
+	// Semáforo para limitar peticiones concurrentes
+	sem := make(chan struct{}, 2)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	entriesBySession := make(map[int][]Driver)
 
-	var allDrivers []Driver
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(s Session) {
+			defer wg.Done()
+			sem <- struct{}{}        // Adquirir semáforo
+			defer func() { <-sem }() // Liberar semáforo
 
-	// Obtener pilotos principales de session_key 9574
-	mainDrivers, err := fetchSpecificDriversFromOpenF1(9574, mainDriverNums)
-	if err != nil {
-		return fmt.Errorf("error obteniendo pilotos principales: %w", err)
-	} else {
-		log.Printf("✅ Obtenidos %d pilotos principales", len(mainDrivers))
-		allDrivers = append(allDrivers, mainDrivers...)
+			drivers, err := source.Drivers(s.SessionKey)
+			if err != nil {
+				log.Printf("❌ Error obteniendo pilotos de session %d: %v", s.SessionKey, err)
+				return
+			}
+			log.Printf("✅ Obtenidos %d pilotos para sesión %d (%s)", len(drivers), s.SessionKey, s.CountryName)
+			mu.Lock()
+			entriesBySession[s.SessionKey] = drivers
+			mu.Unlock()
+		}(session)
 	}
+	wg.Wait()
 
-	// Obtener pilotos adicionales de session_key 9636
-	extraDrivers, err := fetchSpecificDriversFromOpenF1(9636, extraDriverNums)
-	if err != nil {
-		log.Printf("❌ Error obteniendo pilotos adicionales: %v", err)
-	} else {
-		log.Printf("✅ Obtenidos %d pilotos adicionales", len(extraDrivers))
-		allDrivers = append(allDrivers, extraDrivers...)
+	// Recorrer en orden cronológico: los datos del piloto quedan con su
+	// aparición más reciente
+	byNumber := make(map[uint]Driver)
+	var entries []DriverSession
+	for _, s := range sessions {
+		for _, d := range entriesBySession[s.SessionKey] {
+			byNumber[d.DriverNumber] = d
+			entries = append(entries, DriverSession{
+				DriverNumber: d.DriverNumber,
+				SessionKey:   s.SessionKey,
+				TeamName:     d.TeamName,
+				TeamColour:   d.TeamColour,
+			})
+		}
 	}
 
-	// Insertar todos los pilotos en un solo batch
-	if len(allDrivers) > 0 {
-		result := db.CreateInBatches(allDrivers, len(allDrivers))
-		if result.Error != nil {
-			log.Printf("❌ Error insertando pilotos: %v", result.Error)
-
-		}
-		log.Printf("✅ %d pilotos insertados en la base de datos.", len(allDrivers))
-	} else {
+	if len(byNumber) == 0 {
 		log.Printf("❌ No se encontraron pilotos para insertar.")
+		return nil
 	}
-	return nil
-}
 
-func fetchSpecificDriversFromOpenF1(sessionKey int, driverNumbers []uint) ([]Driver, error) {
-	url := fmt.Sprintf("https://api.openf1.org/v1/drivers?session_key=%d", sessionKey)
+	drivers := make([]Driver, 0, len(byNumber))
+	for _, d := range byNumber {
+		drivers = append(drivers, d)
+	}
+
+	syncWriteMu.Lock()
+	defer syncWriteMu.Unlock()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(drivers, 100).Error; err != nil {
+			return fmt.Errorf("error insertando pilotos: %w", err)
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(entries, 500).Error; err != nil {
+			return fmt.Errorf("error insertando inscritos por sesión: %w", err)
+		}
 
-	body, err := fetchWithRetry(url, 3)
+		// El equipo "actual" de cada piloto es el de su sesión más reciente,
+		// aunque se haya ingerido una temporada anterior después
+		return tx.Exec(`
+            UPDATE drivers
+            SET (team_name, team_colour) = (
+                SELECT ds.team_name, ds.team_colour
+                FROM driver_sessions ds
+                JOIN sessions s ON s.session_key = ds.session_key
+                WHERE ds.driver_number = drivers.driver_number
+                ORDER BY s.date_start DESC
+                LIMIT 1
+            )
+            WHERE driver_number IN (SELECT driver_number FROM driver_sessions)
+        `).Error
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error consultando OpenF1 para session %d: %v", sessionKey, err)
+		return err
 	}
 
-	var allDrivers []Driver
-	if err := json.Unmarshal(body, &allDrivers); err != nil {
-		return nil, fmt.Errorf("error al parsear respuesta OpenF1 para session %d: %v", sessionKey, err)
-	}
+	log.Printf("✅ %d pilotos y %d inscripciones por sesión guardados en la base de datos.", len(drivers), len(entries))
+	return nil
+}
 
-	// Crear un mapa para búsqueda rápida
-	targetDrivers := make(map[uint]bool)
-	for _, num := range driverNumbers {
-		targetDrivers[num] = true
-	}
+// sessionEntries devuelve la inscripción de cada piloto en una sesión
+func sessionEntries(sessionKey int) map[uint]DriverSession {
+	var entries []DriverSession
+	db.Where("session_key = ?", sessionKey).Find(&entries)
 
-	var filteredDrivers []Driver
-	for _, driver := range allDrivers {
-		if targetDrivers[driver.DriverNumber] {
-			filteredDrivers = append(filteredDrivers, driver)
-			log.Printf("✅ Piloto encontrado: %s %s (#%d)", driver.FirstName, driver.LastName, driver.DriverNumber)
-		}
+	byDriver := make(map[uint]DriverSession, len(entries))
+	for _, e := range entries {
+		byDriver[e.DriverNumber] = e
 	}
+	return byDriver
+}
 
-	if len(filteredDrivers) != len(driverNumbers) {
-		// Identificar qué pilotos faltan
-		missing := []uint{}
-		for _, num := range driverNumbers {
-			found := false
-			for _, driver := range filteredDrivers {
-				if driver.DriverNumber == num {
-					found = true
-					break
-				}
-			}
-			if !found {
-				missing = append(missing, num)
-			}
-		}
-		log.Printf("⚠️ No se encontraron todos los pilotos. Faltan: %v", missing)
+// seasonEntries devuelve la última inscripción de cada piloto en una
+// temporada, es decir, el equipo con que terminó ese año
+func seasonEntries(year int) map[uint]DriverSession {
+	var entries []DriverSession
+	db.Model(&DriverSession{}).
+		Joins("JOIN sessions ON sessions.session_key = driver_sessions.session_key").
+		Where("sessions.year = ?", year).
+		Order("sessions.date_start ASC").
+		Find(&entries)
+
+	byDriver := make(map[uint]DriverSession, len(entries))
+	for _, e := range entries {
+		byDriver[e.DriverNumber] = e
 	}
+	return byDriver
+}
 
-	return filteredDrivers, nil
+// teamOf devuelve el equipo y color del piloto según las inscripciones
+// dadas; si no hay inscripción se usa el equipo actual del piloto
+func teamOf(entries map[uint]DriverSession, d Driver) (string, string) {
+	if e, ok := entries[d.DriverNumber]; ok && e.TeamName != "" {
+		return e.TeamName, e.TeamColour
+	}
+	return d.TeamName, d.TeamColour
 }
 
 func getDriverDetail(c *gin.Context) {
@@ -226,66 +298,161 @@ func getDriverDetail(c *gin.Context) {
 		driver = drivers[index-1]
 	}
 
-	// Obtener todas las sesiones de una vez
+	// Obtener todas las carreras y sprints de una vez (opcionalmente de una temporada)
+	query := db.Model(&Session{}).Where("session_name IN ?", []string{sessionRace, sessionSprint})
+	if y := c.Query("year"); y != "" {
+		year, err := strconv.Atoi(y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+			return
+		}
+		query = query.Where("year = ?", year)
+	}
+
 	var sessions []Session
-	if err := db.Find(&sessions).Error; err != nil {
+	if err := query.Find(&sessions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las sesiones"})
 		return
 	}
 
 	// Crear un mapa de sesiones para acceso rápido
 	sessionsByKey := make(map[int]Session)
+	sessionKeys := make([]int, 0, len(sessions))
 	for _, s := range sessions {
 		sessionsByKey[s.SessionKey] = s
+		sessionKeys = append(sessionKeys, s.SessionKey)
+	}
+
+	// Equipo con que corrió en cada sesión (puede cambiar durante la temporada)
+	var entries []DriverSession
+	db.Where("driver_number = ?", driver.DriverNumber).Find(&entries)
+	entryBySession := make(map[int]DriverSession, len(entries))
+	for _, e := range entries {
+		entryBySession[e.SessionKey] = e
 	}
 
 	// Resumen de carreras
 	winCount := 0
 	top3Count := 0
+	poleCount := 0
+	sprintWinCount := 0
+	sprintPointsTotal := 0
 	maxSpeed := 0.0
 	results := []gin.H{}
 
+	// Paradas en boxes del piloto en cada sesión, en una consulta
+	type sessionStops struct {
+		SessionKey int
+		Stops      int
+	}
+	var stops []sessionStops
+	db.Model(&PitStop{}).
+		Select("session_key, COUNT(*) AS stops").
+		Where("driver_number = ?", driver.DriverNumber).
+		Group("session_key").
+		Scan(&stops)
+	stopsBySession := make(map[int]int, len(stops))
+	for _, st := range stops {
+		stopsBySession[st.SessionKey] = st.Stops
+	}
+
+	// Resultados finales del piloto en todas sus sesiones (incluidas las
+	// clasificaciones de los sábados, con su grilla), en una consulta
+	var classifications []Classification
+	db.Where("driver_number = ?", driver.DriverNumber).Find(&classifications)
+	finalBySession := make(map[int]Classification, len(classifications))
+	for _, cl := range classifications {
+		finalBySession[cl.SessionKey] = cl
+	}
+
+	// Clasificación de cada fin de semana, para la posición de salida y las
+	// poles; los sprints no tienen (su grilla sale del sprint qualifying)
+	var meetingKeys []int
+	for _, s := range sessions {
+		if s.SessionName == sessionRace && s.MeetingKey != 0 {
+			meetingKeys = append(meetingKeys, s.MeetingKey)
+		}
+	}
+	var qualis []Session
+	if len(meetingKeys) > 0 {
+		db.Where("session_name = ? AND meeting_key IN ?", sessionQualifying, meetingKeys).Find(&qualis)
+	}
+	qualiByMeeting := make(map[int]int, len(qualis))
+	for _, q := range qualis {
+		qualiByMeeting[q.MeetingKey] = q.SessionKey
+	}
+
+	// Mejor vuelta del piloto en cada sesión y vuelta más rápida de cada
+	// sesión, en una consulta cada una
+	var bestLaps []Lap
+	db.Raw(`
+        SELECT l.*
+        FROM laps l
+        JOIN (
+            SELECT session_key, MIN(lap_duration) AS lap_duration
+            FROM laps
+            WHERE driver_number = ? AND lap_duration > 0
+            GROUP BY session_key
+        ) best ON best.session_key = l.session_key AND best.lap_duration = l.lap_duration
+        WHERE l.driver_number = ?
+    `, driver.DriverNumber, driver.DriverNumber).Scan(&bestLaps)
+	bestLapBySession := make(map[int]Lap, len(bestLaps))
+	for _, l := range bestLaps {
+		bestLapBySession[l.SessionKey] = l
+	}
+
+	type sessionBest struct {
+		SessionKey  int
+		LapDuration float64
+	}
+	var fastestLaps []sessionBest
+	db.Model(&Lap{}).
+		Select("session_key, MIN(lap_duration) AS lap_duration").
+		Where("lap_duration > 0 AND session_key IN ?", sessionKeys).
+		Group("session_key").
+		Scan(&fastestLaps)
+	fastestBySession := make(map[int]float64, len(fastestLaps))
+	for _, f := range fastestLaps {
+		fastestBySession[f.SessionKey] = f.LapDuration
+	}
+
 	// Para cada sesión, obtener los datos del piloto
 	for sessionKey, session := range sessionsByKey {
-		// Obtener la posición final del piloto en esta sesión
-		var finalPosition Position
-		if err := db.Where("session_key = ? AND driver_number = ?", sessionKey, driver.DriverNumber).
-			Order("date DESC").
-			First(&finalPosition).Error; err != nil {
-			// Si no hay posición, saltamos esta sesión
+		// Si no tiene clasificación en esta sesión, la saltamos
+		finalPosition, ok := finalBySession[sessionKey]
+		if !ok {
 			continue
 		}
 
-		// Obtener la mejor vuelta del piloto en esta sesión
-		var bestLap Lap
-		if err := db.Where("session_key = ? AND driver_number = ? AND lap_duration > 0", sessionKey, driver.DriverNumber).
-			Order("lap_duration ASC").
-			First(&bestLap).Error; err != nil {
-			// Si no hay vuelta, usamos valores por defecto
-			bestLap = Lap{
-				LapDuration: 0,
-				StSpeed:     0,
-			}
-		}
+		bestLap := bestLapBySession[sessionKey]
+		fastestLap := bestLap.LapDuration > 0 && bestLap.LapDuration == fastestBySession[sessionKey]
 
-		// Obtener la vuelta más rápida de la sesión
-		var fastestLapInSession Lap
-		if err := db.Where("session_key = ? AND lap_duration > 0", sessionKey).
-			Order("lap_duration ASC").
-			First(&fastestLapInSession).Error; err != nil {
-			// Si no hay vuelta más rápida, asumimos que no es la vuelta rápida
-			fastestLapInSession = Lap{
-				DriverNumber: 0,
-				LapDuration:  0,
+		// Posición en la clasificación del fin de semana
+		qualiPosition := 0
+		if session.SessionName == sessionRace {
+			if qualiKey, ok := qualiByMeeting[session.MeetingKey]; ok {
+				qualiPosition = finalBySession[qualiKey].Position
 			}
 		}
 
-		// Actualizar contadores
-		if finalPosition.Position == 1 {
-			winCount++
-		}
-		if finalPosition.Position <= 3 {
-			top3Count++
+		// Actualizar contadores (los sprints se cuentan aparte)
+		if session.IsSprint {
+			if finalPosition.Position == 1 {
+				sprintWinCount++
+			}
+			if finalPosition.Classified {
+				sprintPointsTotal += sprintPoints(session.Year, finalPosition.Position)
+			}
+		} else {
+			if finalPosition.Position == 1 {
+				winCount++
+			}
+			if qualiPosition == 1 {
+				poleCount++
+			}
+			if finalPosition.Classified && finalPosition.Position <= 3 {
+				top3Count++
+			}
 		}
 		if bestLap.StSpeed > maxSpeed {
 			maxSpeed = bestLap.StSpeed
@@ -293,14 +460,31 @@ func getDriverDetail(c *gin.Context) {
 
 		// Formatear el nombre de la carrera
 		raceName := fmt.Sprintf("GP de %s", session.CountryName)
+		if session.IsSprint {
+			raceName = fmt.Sprintf("Sprint de %s", session.CountryName)
+		}
+
+		team, teamColour := driver.TeamName, driver.TeamColour
+		if e, ok := entryBySession[sessionKey]; ok {
+			team, teamColour = e.TeamName, e.TeamColour
+		}
 
 		// Agregar resultado
 		results = append(results, gin.H{
 			"session_key":        sessionKey,
 			"circuit_short_name": session.CircuitShortName,
 			"race":               raceName,
+			"is_sprint":          session.IsSprint,
+			"team":               team,
+			"team_colour":        teamColour,
 			"position":           finalPosition.Position,
-			"fastest_lap":        bestLap.DriverNumber == fastestLapInSession.DriverNumber && bestLap.LapDuration > 0,
+			"status":             finalPosition.Status,
+			"classified":         finalPosition.Classified,
+			"grid_position":      finalPosition.GridPosition,
+			"qualifying":         qualiPosition,
+			"pole":               qualiPosition == 1,
+			"fastest_lap":        fastestLap,
+			"pit_stops":          stopsBySession[sessionKey],
 			"max_speed":          bestLap.StSpeed,
 			"best_lap_duration":  bestLap.LapDuration,
 		})
@@ -316,12 +500,19 @@ func getDriverDetail(c *gin.Context) {
 		"performance_summary": gin.H{
 			"wins":           winCount,
 			"top_3_finishes": top3Count,
+			"poles":          poleCount,
+			"sprint_wins":    sprintWinCount,
+			"sprint_points":  sprintPointsTotal,
 			"max_speed":      maxSpeed,
 		},
 		"race_results": results,
 	})
 }
 
+// errNoRows indica que OpenF1 no tiene registros para la consulta: responde
+// 404 o, a veces, un array vacío
+var errNoRows = errors.New("sin resultados")
+
 // Función auxiliar para hacer peticiones HTTP con reintentos
 func fetchWithRetry(url string, maxRetries int) ([]byte, error) {
 	var resp *http.Response
@@ -386,14 +577,10 @@ func fetchWithRetry(url string, maxRetries int) ([]byte, error) {
 			// Verificar el tipo de resultado
 			switch v := result.(type) {
 			case []interface{}:
+				// Un array vacío es una consulta sin registros, igual que
+				// el 404: no tiene sentido reintentar
 				if len(v) == 0 {
-					log.Printf("⚠️ Array JSON vacío recibido")
-					if i < maxRetries-1 {
-						time.Sleep(backoff)
-						backoff *= 2
-						continue
-					}
-					return nil, fmt.Errorf("array JSON vacío")
+					return nil, fmt.Errorf("array JSON vacío: %w", errNoRows)
 				}
 			case map[string]interface{}:
 				if len(v) == 0 {
@@ -411,6 +598,13 @@ func fetchWithRetry(url string, maxRetries int) ([]byte, error) {
 			return body, nil
 		}
 
+		// OpenF1 responde 404 cuando no hay resultados: no tiene sentido
+		// reintentar
+		if err == nil && resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, fmt.Errorf("HTTP 404: %w", errNoRows)
+		}
+
 		// Manejar errores HTTP
 		if resp != nil {
 			log.Printf("❌ Error HTTP %d recibido", resp.StatusCode)
@@ -428,221 +622,27 @@ func fetchWithRetry(url string, maxRetries int) ([]byte, error) {
 	return nil, fmt.Errorf("error después de %d intentos: %v", maxRetries, err)
 }
 
-func autoPopulateSessionsIfNeeded() error {
-	var count int64
-	db.Model(&Session{}).Count(&count)
-
-	if count > 0 {
-		log.Println("✔️ Tabla de carreras ya tiene datos.")
-		return nil
-	}
-
-	log.Println("📥 Poblando tabla de carreras desde OpenF1...")
-
-	url := "https://api.openf1.org/v1/sessions?session_name=Race&year=2024"
-	log.Printf("🔍 Consultando carreras de 2024: %s", url)
-
-	body, err := fetchWithRetry(url, 3)
-	if err != nil {
-		log.Fatalf("❌ Error consultando sesiones: %v", err)
-	}
-
-	var sessions []Session
-	if err := json.Unmarshal(body, &sessions); err != nil {
-		log.Fatalf("❌ Error parseando sesiones: %v", err)
-	}
-
-	if len(sessions) == 0 {
-		log.Fatalf("❌ No se encontraron carreras para el año 2024")
-	}
-
-	log.Printf("✅ Obtenidas %d carreras para el año 2024", len(sessions))
-
-	// Mostrar información de las carreras encontradas
-	for _, s := range sessions {
-		log.Printf("🏁 Carrera: %s en %s (%s) - session_key: %d",
-			s.SessionName, s.CountryName, s.DateStart, s.SessionKey)
-	}
-
-	// Insertar todas las sesiones en un solo batch
-	if result := db.CreateInBatches(sessions, len(sessions)); result.Error != nil {
-		log.Fatalf("❌ Error insertando carreras: %v", result.Error)
-	}
-
-	log.Printf("✅ %d carreras insertadas en la base de datos.", len(sessions))
-	return nil
-}
-
-func autoPopulatePositionsAndLapsIfNeeded() {
-	var posCount, lapCount int64
-	db.Model(&Position{}).Count(&posCount)
-	db.Model(&Lap{}).Count(&lapCount)
-
-	if posCount > 0 && lapCount > 0 {
-		log.Println("✔️ Tablas de posiciones y vueltas ya tienen datos.")
-		return
-	}
-
-	log.Println("📥 Poblando tablas de posiciones y vueltas desde OpenF1...")
-
-	// Obtener todas las sesiones
-	var sessions []Session
-	if err := db.Find(&sessions).Error; err != nil {
-		log.Printf("❌ Error obteniendo sesiones: %v", err)
-		return
-	}
-
-	if len(sessions) == 0 {
-		log.Printf("❌ No se encontraron sesiones para obtener posiciones y vueltas.")
+func getSessions(c *gin.Context) {
+	// Filtro opcional por tipo: /api/carrera?type=sprint (race, sprint o all)
+	names, ok := raceSessionNames(c.Query("type"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de carrera inválido (race, sprint o all)"})
 		return
 	}
+	query := db.Where("session_name IN ?", names).Order("date_start ASC")
 
-	log.Printf("🔍 Encontradas %d sesiones para procesar", len(sessions))
-
-	// Semáforo para limitar peticiones concurrentes
-	sem := make(chan struct{}, 2)
-	var wg sync.WaitGroup
-
-	// Variables para almacenar resultados
-	var mu sync.Mutex
-	allPositions := make([]Position, 0)
-	allLaps := make([]Lap, 0)
-
-	// Procesar cada sesión
-	for _, session := range sessions {
-		wg.Add(1)
-		go func(s Session) {
-			defer wg.Done()
-			sem <- struct{}{}        // Adquirir semáforo
-			defer func() { <-sem }() // Liberar semáforo
-
-			log.Printf("🔄 Procesando sesión %d: %s en %s", s.SessionKey, s.SessionName, s.CountryName)
-
-			// 1. Obtener posiciones
-			positions, err := fetchPositionsFromAPI(s.SessionKey)
-			if err != nil {
-				log.Printf("❌ Error obteniendo posiciones para sesión %d: %v", s.SessionKey, err)
-			} else {
-				log.Printf("✅ Obtenidas %d posiciones para sesión %d", len(positions), s.SessionKey)
-				mu.Lock()
-				allPositions = append(allPositions, positions...)
-				mu.Unlock()
-			}
-
-			// 2. Obtener vueltas
-			laps, err := fetchLapsFromAPI(s.SessionKey)
-			if err != nil {
-				log.Printf("❌ Error obteniendo vueltas para sesión %d: %v", s.SessionKey, err)
-			} else {
-				log.Printf("✅ Obtenidas %d vueltas para sesión %d", len(laps), s.SessionKey)
-				mu.Lock()
-				allLaps = append(allLaps, laps...)
-				mu.Unlock()
-			}
-		}(session)
-	}
-
-	// Esperar a que todas las goroutines terminen
-	wg.Wait()
-
-	log.Printf("📊 Resultados finales: %d posiciones y %d vueltas obtenidas", len(allPositions), len(allLaps))
-
-	// Insertar posiciones en la base de datos
-	if len(allPositions) > 0 {
-		batchSize := 1000
-		var insertedPos int
-		for i := 0; i < len(allPositions); i += batchSize {
-			end := i + batchSize
-			if end > len(allPositions) {
-				end = len(allPositions)
-			}
-			batch := allPositions[i:end]
-
-			if result := db.CreateInBatches(batch, len(batch)); result.Error != nil {
-				log.Printf("❌ Error insertando lote de posiciones %d-%d: %v", i, end, result.Error)
-			} else {
-				insertedPos += int(result.RowsAffected)
-				log.Printf("✅ Insertadas %d posiciones (lote %d-%d)", result.RowsAffected, i, end)
-			}
+	// Filtro opcional por temporada: /api/carrera?year=2024
+	if y := c.Query("year"); y != "" {
+		year, err := strconv.Atoi(y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+			return
 		}
-		log.Printf("✅ Total de %d posiciones insertadas en la base de datos", insertedPos)
-	} else {
-		log.Printf("⚠️ No se encontraron posiciones para insertar")
+		query = query.Where("year = ?", year)
 	}
 
-	// Insertar vueltas en la base de datos
-	if len(allLaps) > 0 {
-		batchSize := 1000
-		var insertedLaps int
-		for i := 0; i < len(allLaps); i += batchSize {
-			end := i + batchSize
-			if end > len(allLaps) {
-				end = len(allLaps)
-			}
-			batch := allLaps[i:end]
-
-			if result := db.CreateInBatches(batch, len(batch)); result.Error != nil {
-				log.Printf("❌ Error insertando lote de vueltas %d-%d: %v", i, end, result.Error)
-			} else {
-				insertedLaps += int(result.RowsAffected)
-				log.Printf("✅ Insertadas %d vueltas (lote %d-%d)", result.RowsAffected, i, end)
-			}
-		}
-		log.Printf("✅ Total de %d vueltas insertadas en la base de datos", insertedLaps)
-	} else {
-		log.Printf("⚠️ No se encontraron vueltas para insertar")
-	}
-}
-
-func fetchPositionsFromAPI(sessionKey int) ([]Position, error) {
-	url := fmt.Sprintf("https://api.openf1.org/v1/position?session_key=%d", sessionKey)
-
-	log.Printf("🔍 Consultando posiciones de sesión %d: %s", sessionKey, url)
-
-	body, err := fetchWithRetry(url, 3)
-	if err != nil {
-		return nil, fmt.Errorf("error consultando posiciones: %v", err)
-	}
-
-	var positions []Position
-	if err := json.Unmarshal(body, &positions); err != nil {
-		return nil, fmt.Errorf("error parseando posiciones: %v", err)
-	}
-
-	// Asegurarse de que todas las posiciones tengan el session_key correcto
-	for i := range positions {
-		positions[i].SessionKey = sessionKey
-	}
-
-	return positions, nil
-}
-
-func fetchLapsFromAPI(sessionKey int) ([]Lap, error) {
-	url := fmt.Sprintf("https://api.openf1.org/v1/laps?session_key=%d", sessionKey)
-
-	log.Printf("🔍 Consultando vueltas de sesión %d: %s", sessionKey, url)
-
-	body, err := fetchWithRetry(url, 3)
-	if err != nil {
-		return nil, fmt.Errorf("error consultando vueltas: %v", err)
-	}
-
-	var laps []Lap
-	if err := json.Unmarshal(body, &laps); err != nil {
-		return nil, fmt.Errorf("error parseando vueltas: %v", err)
-	}
-
-	// Asegurarse de que todas las vueltas tengan el session_key correcto
-	for i := range laps {
-		laps[i].SessionKey = sessionKey
-	}
-
-	return laps, nil
-}
-
-func getSessions(c *gin.Context) {
 	var sessions []Session
-	result := db.Find(&sessions)
+	result := query.Find(&sessions)
 
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las carreras"})
@@ -653,6 +653,8 @@ func getSessions(c *gin.Context) {
 	for _, s := range sessions {
 		carreras = append(carreras, gin.H{
 			"session_key":        s.SessionKey,
+			"session_name":       s.SessionName,
+			"is_sprint":          s.IsSprint,
 			"country_name":       s.CountryName,
 			"date_start":         s.DateStart,
 			"year":               s.Year,
@@ -680,38 +682,36 @@ func getSessionDetail(c *gin.Context) {
 		return
 	}
 
-	var driverNums []uint
-	if err := db.
-		Model(&Position{}).
-		Distinct("driver_number").
-		Where("session_key = ?", session.SessionKey).
-		Pluck("driver_number", &driverNums).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al listar pilotos en posiciones"})
+	// Clasificación final calculada al ingerir la sesión
+	positions, err := sessionClassification(session.SessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
 		return
 	}
 
-	// 2) Para cada piloto, obtenemos su posición final (fecha más reciente)
-	var positions []Position
-	for _, dn := range driverNums {
-		var p Position
-		if err := db.
-			Where("session_key = ? AND driver_number = ?", session.SessionKey, dn).
-			Order("date DESC").
-			First(&p).Error; err != nil {
-			// si algo falla con este piloto, lo saltamos
-			continue
-		}
-		positions = append(positions, p)
+	// Equipo con que corrió cada piloto en esta carrera
+	entries := sessionEntries(session.SessionKey)
+
+	// Grilla de partida (primera muestra de posición de cada piloto)
+	grid, err := startingPositions(db, session.SessionKey)
+	if err != nil {
+		log.Printf("Error obteniendo grilla de partida: %v", err)
+	}
+
+	// Lugares ganados y cambios de posición de cada piloto
+	changes, err := racePositionChanges(session.SessionKey)
+	if err != nil {
+		log.Printf("Error calculando cambios de posición: %v", err)
+	}
+	changesBy := make(map[uint]positionChange, len(changes))
+	for _, pc := range changes {
+		changesBy[pc.DriverNumber] = pc
 	}
 
 	resultados := []gin.H{}
 
 	// Mapa para rastrear pilotos ya mostrados
 	pilotosMostrados := make(map[uint]bool)
-	// Ordenar posiciones por posición ascendente
-	sort.Slice(positions, func(i, j int) bool {
-		return positions[i].Position < positions[j].Position
-	})
 	// Primero mostrar top 3
 	for i := 0; i < 3 && i < len(positions); i++ {
 		p := positions[i]
@@ -720,15 +720,21 @@ func getSessionDetail(c *gin.Context) {
 			log.Printf("Error obteniendo piloto: %v", err)
 			continue
 		}
+		team, teamColour := teamOf(entries, driver)
 		resultados = append(resultados, gin.H{
-			"position": fmt.Sprintf("%d", p.Position),
-			"driver":   fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
-			"team":     driver.TeamName,
-			"country":  driver.CountryCode,
+			"position":    fmt.Sprintf("%d", p.Position),
+			"grid":        grid[p.DriverNumber],
+			"status":      p.Status,
+			"gained":      changesBy[p.DriverNumber].Gained,
+			"changes":     changesBy[p.DriverNumber].Changes,
+			"driver":      fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+			"team":        team,
+			"team_colour": teamColour,
+			"country":     driver.CountryCode,
 		})
 		pilotosMostrados[p.DriverNumber] = true
 	}
-	ultimo := Position{}
+	ultimo := Classification{}
 	if len(positions) > 0 {
 		ultimo = positions[len(positions)-1]
 	}
@@ -739,11 +745,17 @@ func getSessionDetail(c *gin.Context) {
 		if err := db.First(&driver, "driver_number = ?", ultimo.DriverNumber).Error; err != nil {
 			log.Printf("Error obteniendo piloto: %v", err)
 		} else {
+			team, teamColour := teamOf(entries, driver)
 			resultados = append(resultados, gin.H{
-				"position": "Último",
-				"driver":   fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
-				"team":     driver.TeamName,
-				"country":  driver.CountryCode,
+				"position":    "Último",
+				"grid":        grid[ultimo.DriverNumber],
+				"status":      ultimo.Status,
+				"gained":      changesBy[ultimo.DriverNumber].Gained,
+				"changes":     changesBy[ultimo.DriverNumber].Changes,
+				"driver":      fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+				"team":        team,
+				"team_colour": teamColour,
+				"country":     driver.CountryCode,
 			})
 		}
 	}
@@ -789,14 +801,68 @@ func getSessionDetail(c *gin.Context) {
 		}
 	}
 
+	// POLE POSITION - Primero de la clasificación del fin de semana
+	var pole gin.H
+	if quali, qualiPositions := qualifyingPositions(session); len(qualiPositions) > 0 {
+		for dn, pos := range qualiPositions {
+			if pos != 1 {
+				continue
+			}
+			var poleDriver Driver
+			if err := db.First(&poleDriver, "driver_number = ?", dn).Error; err != nil {
+				log.Printf("Error obteniendo piloto de la pole: %v", err)
+				break
+			}
+			var poleLap Lap
+			db.Where("session_key = ? AND driver_number = ? AND lap_duration > 0", quali.SessionKey, dn).
+				Order("lap_duration ASC").
+				First(&poleLap)
+
+			team, teamColour := teamOf(entries, poleDriver)
+			pole = gin.H{
+				"driver":      fmt.Sprintf("%s %s", poleDriver.FirstName, poleDriver.LastName),
+				"team":        team,
+				"team_colour": teamColour,
+				"session_key": quali.SessionKey,
+				"lap_time":    poleLap.LapDuration,
+			}
+			break
+		}
+	}
+
+	// MÁS LUGARES GANADOS
+	var gainer gin.H
+	if best, ok := mostPlacesGained(changes); ok {
+		var gainerDriver Driver
+		if err := db.First(&gainerDriver, "driver_number = ?", best.DriverNumber).Error; err != nil {
+			log.Printf("Error obteniendo piloto que más lugares ganó: %v", err)
+		} else {
+			team, teamColour := teamOf(entries, gainerDriver)
+			gainer = gin.H{
+				"driver":      fmt.Sprintf("%s %s", gainerDriver.FirstName, gainerDriver.LastName),
+				"team":        team,
+				"team_colour": teamColour,
+				"grid":        best.Grid,
+				"position":    best.Finish,
+				"gained":      best.Gained,
+			}
+		}
+	}
+
 	// RESPUESTA
 	c.JSON(http.StatusOK, gin.H{
 		"race_id":            session.SessionKey,
+		"session_name":       session.SessionName,
+		"is_sprint":          session.IsSprint,
 		"country_name":       session.CountryName,
 		"date_start":         session.DateStart,
 		"year":               session.Year,
 		"circuit_short_name": session.CircuitShortName,
 		"results":            resultados,
+		"pole_position":      pole,
+		"most_places_gained": gainer,
+		"weather":            weatherSummary(session.SessionKey),
+		"gaps":               finalGaps(session.SessionKey, positions),
 		"fastest_lap": gin.H{
 			"driver":     fmt.Sprintf("%s %s", fastDriver.FirstName, fastDriver.LastName),
 			"total_time": fastest.LapDuration,
@@ -811,32 +877,45 @@ func getSessionDetail(c *gin.Context) {
 	})
 }
 
+// GET /api/temporada/resumen y /api/temporada/:year/resumen
 func getSeasonSummary(c *gin.Context) {
 	type Count struct {
 		DriverNumber uint
 		Count        int
 	}
 
-	// === VICTORIAS ===
+	// Sin año en la ruta se usa la temporada más reciente configurada
+	year := defaultSeason()
+	if y := c.Param("year"); y != "" {
+		var err error
+		year, err = strconv.Atoi(y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+			return
+		}
+	}
+
+	var sessionCount int64
+	db.Model(&Session{}).Where("year = ? AND session_name = ?", year, sessionRace).Count(&sessionCount)
+	if sessionCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+
+	// === VICTORIAS (clasificación final de cada carrera) ===
 	var wins []Count
-	db.Raw(`
-        SELECT driver_number, COUNT(*) as count
-        FROM positions
-        WHERE position = 1
-        GROUP BY driver_number
-        ORDER BY count DESC
-        LIMIT 3
-    `).Scan(&wins)
+	db.Raw(finalWinnersSQL, sessionRace, year).Scan(&wins)
 
 	// === VUELTAS RÁPIDAS (fast laps) con CTE + JOIN ===
 	var fastLaps []Count
 	db.Raw(`
         WITH best_times AS (
-            SELECT session_key,
-                   MIN(lap_duration) AS best_time
-            FROM laps
-            WHERE lap_duration > 0
-            GROUP BY session_key
+            SELECT l.session_key,
+                   MIN(l.lap_duration) AS best_time
+            FROM laps l
+            JOIN sessions s ON s.session_key = l.session_key
+            WHERE l.lap_duration > 0 AND s.session_name = ? AND s.year = ?
+            GROUP BY l.session_key
         )
         SELECT l.driver_number, COUNT(*) AS count
         FROM laps l
@@ -846,18 +925,47 @@ func getSeasonSummary(c *gin.Context) {
         GROUP BY l.driver_number
         ORDER BY count DESC
         LIMIT 3
-    `).Scan(&fastLaps)
+    `, sessionRace, year).Scan(&fastLaps)
 
-	// === POLES ===
+	// === POLES (clasificación final de cada qualifying) ===
 	var poles []Count
-	db.Raw(`
-        SELECT driver_number, COUNT(*) as count
-        FROM positions
-        WHERE position = 1
-        GROUP BY driver_number
-        ORDER BY count DESC
-        LIMIT 3
-    `).Scan(&poles)
+	db.Raw(finalWinnersSQL, sessionQualifying, year).Scan(&poles)
+
+	// === SPRINTS (victorias y puntos, aparte de las carreras) ===
+	var sprintWins []Count
+	db.Raw(finalWinnersSQL, sessionSprint, year).Scan(&sprintWins)
+
+	var sprints []Session
+	db.Where("year = ? AND session_name = ?", year, sessionSprint).Find(&sprints)
+	pointsByDriver := make(map[uint]int)
+	for _, s := range sprints {
+		positions, err := sessionClassification(s.SessionKey)
+		if err != nil {
+			log.Printf("Error obteniendo clasificación del sprint %d: %v", s.SessionKey, err)
+			continue
+		}
+		for _, p := range positions {
+			if pts := sprintPoints(year, p.Position); p.Classified && pts > 0 {
+				pointsByDriver[p.DriverNumber] += pts
+			}
+		}
+	}
+	var sprintPointsTop []Count
+	for dn, pts := range pointsByDriver {
+		sprintPointsTop = append(sprintPointsTop, Count{DriverNumber: dn, Count: pts})
+	}
+	sort.Slice(sprintPointsTop, func(i, j int) bool {
+		if sprintPointsTop[i].Count != sprintPointsTop[j].Count {
+			return sprintPointsTop[i].Count > sprintPointsTop[j].Count
+		}
+		return sprintPointsTop[i].DriverNumber < sprintPointsTop[j].DriverNumber
+	})
+	if len(sprintPointsTop) > 3 {
+		sprintPointsTop = sprintPointsTop[:3]
+	}
+
+	// Equipo de cada piloto en esa temporada
+	entries := seasonEntries(year)
 
 	// Formateo común
 	format := func(cs []Count) []gin.H {
@@ -865,22 +973,26 @@ func getSeasonSummary(c *gin.Context) {
 		for i, c := range cs {
 			var d Driver
 			db.First(&d, "driver_number = ?", c.DriverNumber)
+			team, teamColour := teamOf(entries, d)
 			out = append(out, gin.H{
-				"position": i + 1,
-				"driver":   fmt.Sprintf("%s %s", d.FirstName, d.LastName),
-				"team":     d.TeamName,
-				"country":  d.CountryCode,
-				"count":    c.Count,
+				"position":    i + 1,
+				"driver":      fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+				"team":        team,
+				"team_colour": teamColour,
+				"country":     d.CountryCode,
+				"count":       c.Count,
 			})
 		}
 		return out
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"season":               2024,
+		"season":               year,
 		"top_3_winners":        format(wins),
 		"top_3_fastest_laps":   format(fastLaps),
 		"top_3_pole_positions": format(poles),
+		"top_3_sprint_winners": format(sprintWins),
+		"top_3_sprint_points":  format(sprintPointsTop),
 	})
 }
 
@@ -910,6 +1022,9 @@ func getSessionPositions(c *gin.Context) {
 		return
 	}
 
+	// Equipo con que corrió cada piloto en esta sesión
+	entries := sessionEntries(sessionKey)
+
 	// Create response with driver details
 	var response []gin.H
 	for _, pos := range positions {
@@ -918,12 +1033,14 @@ func getSessionPositions(c *gin.Context) {
 			log.Printf("Error obteniendo piloto: %v", err)
 			continue
 		}
+		team, teamColour := teamOf(entries, driver)
 		response = append(response, gin.H{
-			"position": pos.Position,
-			"driver":   fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
-			"team":     driver.TeamName,
-			"country":  driver.CountryCode,
-			"date":     pos.Date,
+			"position":    pos.Position,
+			"driver":      fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+			"team":        team,
+			"team_colour": teamColour,
+			"country":     driver.CountryCode,
+			"date":        pos.Date,
 		})
 	}
 
@@ -933,11 +1050,40 @@ func getSessionPositions(c *gin.Context) {
 	})
 }
 func startServer() {
+	if err := loadConfig(); err != nil {
+		log.Fatal("Error cargando configuración:", err)
+	}
+
+	var err error
+	source, err = newDataSource(cfg)
+	if err != nil {
+		log.Fatal("Error creando fuente de datos:", err)
+	}
+	if cfg.Source == sourceOpenF1 {
+		log.Printf("🌐 Usando OpenF1 en %s", cfg.OpenF1BaseURL)
+	} else {
+		log.Printf("📂 Usando fuente de datos %q (%s)", cfg.Source, cfg.SourceDir)
+	}
+
 	initDatabase()
 
-	autoPopulateDriversIfNeeded()
-	autoPopulateSessionsIfNeeded()
-	autoPopulatePositionsAndLapsIfNeeded()
+	// Sincronización incremental: solo lo nuevo, pendiente o fallido
+	// Sin conexión con OpenF1 se sigue sirviendo lo que ya está en proxy.db;
+	// con -sync-only el error se reporta en el código de salida
+	if _, err := runSync(nil, false); err != nil {
+		if cfg.SyncOnly {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("❌ %v", err)
+	}
+	if cfg.SyncOnly {
+		log.Println("✅ Sincronización terminada (-sync-only), saliendo.")
+		return
+	}
+
+	// Durante la temporada las carreras nuevas se ingieren solas
+	scheduler = newSyncScheduler(cfg.SyncInterval.Duration)
+	scheduler.start()
 
 	r := gin.Default()
 
@@ -947,11 +1093,36 @@ func startServer() {
 		api.GET("/carrera", getSessions)
 		api.GET("/corredor/detalle/:id", getDriverDetail)
 		api.GET("/carrera/detalle/:id", getSessionDetail)
+		api.GET("/carrera/clasificacion/:id", getSessionClassification)
+		api.GET("/carrera/vueltas/:id", getLapChart)
+		api.GET("/carrera/estrategia/:id", getRaceStrategy)
+		api.GET("/carrera/boxes/:id", getRacePitStops)
+		api.GET("/carrera/clima/:id", getRaceWeather)
+		api.GET("/carrera/direccion/:id", getRaceControl)
+		api.GET("/carrera/intervalos/:id", getRaceIntervals)
+		api.GET("/carrera/intervalos/:id/:driver", getDriverIntervals)
+		api.GET("/carrera/telemetria/:id/:driver/:lap", getLapTelemetry)
 		api.GET("/temporada/resumen", getSeasonSummary)
+		api.GET("/temporada/:year/resumen", getSeasonSummary)
+		api.GET("/temporada/:year/clasificacion", getDriverStandings)
+		api.GET("/temporada/:year/constructores", getConstructorStandings)
+		api.GET("/temporada/:year/progresion", getStandingsProgression)
+		api.GET("/temporada/:year/analitica", getSeasonAnalytics)
+		api.GET("/equipo/:name", getTeamDetail)
 		api.GET("/carrera/posiciones", getAllSessions)
 		api.GET("/carrera/posiciones/:id", getSessionPositions)
 	}
 
+	// La API de administración exige admin_token o pedidos locales
+	admin := api.Group("/admin", requireAdmin())
+	{
+		admin.POST("/sync", postSync)
+		admin.POST("/sync/session/:id", postSyncSession)
+		admin.GET("/sync/jobs", getSyncJobs)
+		admin.GET("/sync/jobs/:id", getSyncJob)
+		admin.GET("/sync/schedule", getSyncSchedule)
+	}
+
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal("No se pudo iniciar el servidor:", err)
 	}
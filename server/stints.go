@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stint es un tramo de carrera de un piloto con un mismo juego de
+// neumáticos, tal como lo publica OpenF1
+type Stint struct {
+	SessionKey     int    `json:"session_key" gorm:"uniqueIndex:idx_stints_natural,priority:1"`
+	DriverNumber   uint   `json:"driver_number" gorm:"uniqueIndex:idx_stints_natural,priority:2"`
+	StintNumber    int    `json:"stint_number" gorm:"uniqueIndex:idx_stints_natural,priority:3"`
+	Compound       string `json:"compound"`
+	LapStart       int    `json:"lap_start"`
+	LapEnd         int    `json:"lap_end"`
+	TyreAgeAtStart int    `json:"tyre_age_at_start"`
+}
+
+// stintForLap busca el stint que cubre una vuelta (stints ordenados)
+func stintForLap(stints []Stint, lap int) (Stint, bool) {
+	for _, st := range stints {
+		if lap >= st.LapStart && (st.LapEnd == 0 || lap <= st.LapEnd) {
+			return st, true
+		}
+	}
+	return Stint{}, false
+}
+
+// GET /api/carrera/estrategia/:id
+func getRaceStrategy(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	var stints []Stint
+	if err := db.Where("session_key = ?", sessionKey).Order("driver_number ASC, stint_number ASC").Find(&stints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los stints"})
+		return
+	}
+	stintsBy := make(map[uint][]Stint)
+	for _, st := range stints {
+		stintsBy[st.DriverNumber] = append(stintsBy[st.DriverNumber], st)
+	}
+
+	var laps []Lap
+	if err := db.Where("session_key = ?", sessionKey).Order("lap_number ASC").Find(&laps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las vueltas"})
+		return
+	}
+	lapsBy := make(map[uint][]Lap)
+	for _, l := range laps {
+		lapsBy[l.DriverNumber] = append(lapsBy[l.DriverNumber], l)
+	}
+
+	messages, err := sessionRaceControl(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los mensajes de dirección de carrera"})
+		return
+	}
+	periods := neutralizedPeriods(messages)
+
+	// Pilotos en el orden de la clasificación final
+	rows, err := sessionClassification(sessionKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la clasificación"})
+		return
+	}
+	drivers := driversByNumber()
+	entries := sessionEntries(sessionKey)
+
+	out := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		driverStints := stintsBy[r.DriverNumber]
+
+		strategy := make([]gin.H, 0, len(driverStints))
+		for _, st := range driverStints {
+			stintLaps := 0
+			if st.LapEnd >= st.LapStart {
+				stintLaps = st.LapEnd - st.LapStart + 1
+			}
+			strategy = append(strategy, gin.H{
+				"stint":             st.StintNumber,
+				"compound":          st.Compound,
+				"lap_start":         st.LapStart,
+				"lap_end":           st.LapEnd,
+				"laps":              stintLaps,
+				"tyre_age_at_start": st.TyreAgeAtStart,
+			})
+		}
+
+		// Cada vuelta con el compuesto, la edad del neumático y si se corrió
+		// bajo safety car, VSC o bandera roja (para excluirla del ritmo)
+		lapTimes := make([]gin.H, 0, len(lapsBy[r.DriverNumber]))
+		for _, l := range lapsBy[r.DriverNumber] {
+			compound, tyreAge := "", 0
+			if st, ok := stintForLap(driverStints, l.LapNumber); ok {
+				compound = st.Compound
+				tyreAge = st.TyreAgeAtStart + l.LapNumber - st.LapStart
+			}
+			var neutralized interface{}
+			if start, ok := parseOpenF1Time(l.DateStart); ok {
+				if kind := lapNeutralization(periods, start, l.LapDuration); kind != "" {
+					neutralized = kind
+				}
+			}
+			lapTimes = append(lapTimes, gin.H{
+				"lap_number":   l.LapNumber,
+				"lap_duration": l.LapDuration,
+				"compound":     compound,
+				"tyre_age":     tyreAge,
+				"neutralized":  neutralized,
+			})
+		}
+
+		d := drivers[r.DriverNumber]
+		team, teamColour := teamOf(entries, d)
+		out = append(out, gin.H{
+			"driver_number": r.DriverNumber,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"team":          team,
+			"team_colour":   teamColour,
+			"position":      r.Position,
+			"status":        r.Status,
+			"stints":        strategy,
+			"laps":          lapTimes,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"drivers":            out,
+	})
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Estados de sincronización de una sesión
+const (
+	syncPending  = "pending"
+	syncComplete = "complete"
+	syncFailed   = "failed"
+)
+
+// SyncState guarda el estado de ingesta de posiciones y vueltas de cada
+// sesión, para reintentar solo lo que falta o falló.
+type SyncState struct {
+	SessionKey   int        `json:"session_key" gorm:"primaryKey;autoIncrement:false"`
+	Status       string     `json:"status" gorm:"index"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	Positions    int        `json:"positions"`
+	Laps         int        `json:"laps"`
+	Error        string     `json:"error"`
+}
+
+// Claves naturales usadas en los upserts
+var (
+	positionKey = []clause.Column{{Name: "session_key"}, {Name: "driver_number"}, {Name: "date"}}
+	lapKey      = []clause.Column{{Name: "session_key"}, {Name: "driver_number"}, {Name: "lap_number"}}
+	messageKey  = []clause.Column{{Name: "session_key"}, {Name: "date"}, {Name: "message"}}
+	stintKey    = []clause.Column{{Name: "session_key"}, {Name: "driver_number"}, {Name: "stint_number"}}
+	pitStopKey  = []clause.Column{{Name: "session_key"}, {Name: "driver_number"}, {Name: "date"}}
+	weatherKey  = []clause.Column{{Name: "session_key"}, {Name: "date"}}
+	intervalKey = []clause.Column{{Name: "session_key"}, {Name: "driver_number"}, {Name: "date"}}
+)
+
+// Las escrituras de la sincronización se serializan: SQLite no admite
+// varios escritores a la vez
+var syncWriteMu sync.Mutex
+
+// syncSummary resume lo que hizo una sincronización
+type syncSummary struct {
+	NewSessions int `json:"new_sessions"`
+	Completed   int `json:"completed"`
+	Failed      int `json:"failed"`
+	Telemetry   int `json:"telemetry_samples"`
+}
+
+// runSync ejecuta una sincronización completa e incremental: agrega las
+// carreras nuevas, sus inscritos y las posiciones y vueltas de las sesiones
+// pendientes o fallidas (o de todas, con force). Se usa al iniciar, con
+// -sync-only, desde el sincronizador en segundo plano y desde la API de
+// administración. job puede ser nil si nadie sigue el progreso.
+func runSync(job *syncJob, force bool) (syncSummary, error) {
+	var summary syncSummary
+
+	// Si falla la lista de sesiones igual se sincronizan las pendientes, pero
+	// la ejecución termina con error para que se vea en el job y en el
+	// sincronizador
+	added, listErr := syncSessionList()
+	summary.NewSessions = added
+
+	if err := autoPopulateDriversIfNeeded(); err != nil {
+		log.Printf("❌ %v", err)
+	}
+
+	if force {
+		var sessions []Session
+		if err := db.Order("date_start ASC").Find(&sessions).Error; err != nil {
+			return summary, fmt.Errorf("error obteniendo sesiones: %w", err)
+		}
+		log.Printf("🔄 Resincronizando las %d sesiones", len(sessions))
+		summary.Completed, summary.Failed = syncSessions(sessions, job)
+	} else {
+		summary.Completed, summary.Failed = syncPendingSessions(job)
+	}
+
+	summary.Telemetry = syncTelemetry(force)
+	return summary, listErr
+}
+
+// sessionFinished indica si la sesión ya terminó (o, si no se conoce el
+// final, si ya empezó), es decir, si tiene sentido descargar sus datos
+func sessionFinished(s Session, now time.Time) bool {
+	date := s.DateEnd
+	if date == "" {
+		date = s.DateStart
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		log.Printf("⚠️ Fecha inválida en sesión %d: %q", s.SessionKey, date)
+		return false
+	}
+	return t.Before(now)
+}
+
+// syncSessionList consulta las sesiones ingeridas (carreras y
+// clasificaciones) de cada temporada configurada y guarda las ya
+// disputadas. Las nuevas quedan pendientes; las conocidas solo actualizan
+// sus datos (por ejemplo meeting_key en bases antiguas). Devuelve cuántas
+// sesiones se agregaron; si alguna consulta falla sigue con las demás y
+// devuelve los errores juntos.
+func syncSessionList() (int, error) {
+	total := 0
+	now := time.Now()
+	var failures []string
+	for _, year := range cfg.Years {
+		for _, name := range ingestedSessionNames {
+			added, err := syncSessionsOf(year, name, now)
+			if err != nil {
+				failures = append(failures, err.Error())
+				continue
+			}
+			total += added
+		}
+	}
+	if len(failures) > 0 {
+		return total, fmt.Errorf("error sincronizando la lista de sesiones: %s", strings.Join(failures, "; "))
+	}
+	return total, nil
+}
+
+func syncSessionsOf(year int, name string, now time.Time) (int, error) {
+	log.Printf("🔍 Consultando sesiones %s de %d...", name, year)
+
+	sessions, err := source.Sessions(year, name)
+	if err != nil {
+		log.Printf("❌ Error consultando sesiones %s de %d: %v", name, year, err)
+		return 0, fmt.Errorf("error consultando sesiones %s de %d: %w", name, year, err)
+	}
+
+	if len(sessions) == 0 {
+		log.Printf("ℹ️ No hay sesiones %s publicadas para el año %d", name, year)
+		return 0, nil
+	}
+
+	var known []int
+	db.Model(&Session{}).Where("year = ? AND session_name = ?", year, name).Pluck("session_key", &known)
+	knownKeys := make(map[int]bool, len(known))
+	for _, k := range known {
+		knownKeys[k] = true
+	}
+
+	var finished []Session
+	var states []SyncState
+	upcoming := 0
+	for _, s := range sessions {
+		// Las sesiones futuras se agregan cuando ya se hayan corrido
+		if !sessionFinished(s, now) {
+			upcoming++
+			continue
+		}
+		s.IsSprint = s.SessionName == sessionSprint
+		finished = append(finished, s)
+		if !knownKeys[s.SessionKey] {
+			states = append(states, SyncState{SessionKey: s.SessionKey, Status: syncPending})
+			log.Printf("🏁 Sesión nueva: %s en %s (%s) - session_key: %d",
+				s.SessionName, s.CountryName, s.DateStart, s.SessionKey)
+		}
+	}
+
+	if len(finished) == 0 {
+		log.Printf("✔️ Sin sesiones %s disputadas en %d (%d por disputar).", name, year, upcoming)
+		return 0, nil
+	}
+
+	syncWriteMu.Lock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(finished, len(finished)).Error; err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(states, len(states)).Error
+	})
+	syncWriteMu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("error insertando sesiones %s de %d: %w", name, year, err)
+	}
+
+	if len(states) == 0 {
+		log.Printf("✔️ Sesiones %s de %d al día (%d por disputar).", name, year, upcoming)
+	} else {
+		log.Printf("✅ %d sesiones %s nuevas de %d insertadas en la base de datos.", len(states), name, year)
+	}
+	return len(states), nil
+}
+
+// adoptExistingSessions marca como completas las sesiones que ya tenían
+// posiciones y vueltas antes de existir sync_states, para no volver a
+// descargarlas tras actualizar una proxy.db antigua
+func adoptExistingSessions() {
+	result := db.Exec(`
+        INSERT INTO sync_states (session_key, status, last_synced_at, positions, laps, error)
+        SELECT s.session_key, ?, CURRENT_TIMESTAMP,
+               (SELECT COUNT(*) FROM positions p WHERE p.session_key = s.session_key),
+               (SELECT COUNT(*) FROM laps l WHERE l.session_key = s.session_key),
+               ''
+        FROM sessions s
+        WHERE s.session_key NOT IN (SELECT session_key FROM sync_states)
+          AND EXISTS (SELECT 1 FROM positions p WHERE p.session_key = s.session_key)
+          AND EXISTS (SELECT 1 FROM laps l WHERE l.session_key = s.session_key)
+    `, syncComplete)
+	if result.Error != nil {
+		log.Printf("❌ Error adoptando sesiones existentes: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("✅ %d sesiones existentes marcadas como completas", result.RowsAffected)
+	}
+}
+
+// pendingSessions devuelve las sesiones sin sincronizar o cuya última
+// sincronización falló
+func pendingSessions() ([]Session, error) {
+	var sessions []Session
+	err := db.
+		Where("session_key NOT IN (?)", db.Model(&SyncState{}).Select("session_key").Where("status = ?", syncComplete)).
+		Order("date_start ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// syncPendingSessions descarga posiciones y vueltas solo de las sesiones
+// pendientes o fallidas. Devuelve cuántas se completaron y cuántas fallaron.
+func syncPendingSessions(job *syncJob) (int, int) {
+	adoptExistingSessions()
+
+	sessions, err := pendingSessions()
+	if err != nil {
+		log.Printf("❌ Error obteniendo sesiones pendientes: %v", err)
+		return 0, 0
+	}
+
+	if len(sessions) == 0 {
+		log.Println("✔️ Posiciones y vueltas al día en todas las sesiones.")
+		return 0, 0
+	}
+
+	log.Printf("🔍 Encontradas %d sesiones pendientes para procesar", len(sessions))
+	return syncSessions(sessions, job)
+}
+
+// syncSessions procesa cada sesión en paralelo (con semáforo) y guarda su
+// resultado apenas termina
+func syncSessions(sessions []Session, job *syncJob) (int, int) {
+	// Semáforo para limitar peticiones concurrentes
+	sem := make(chan struct{}, 2)
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	completed, failed := 0, 0
+
+	for _, session := range sessions {
+		job.queue(session)
+	}
+
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(s Session) {
+			defer wg.Done()
+			sem <- struct{}{}        // Adquirir semáforo
+			defer func() { <-sem }() // Liberar semáforo
+
+			state := syncSession(s, job)
+
+			mu.Lock()
+			if state.Status == syncComplete {
+				completed++
+			} else {
+				failed++
+			}
+			mu.Unlock()
+		}(session)
+	}
+
+	// Esperar a que todas las goroutines terminen
+	wg.Wait()
+
+	log.Printf("📊 Sincronización terminada: %d sesiones completas, %d fallidas", completed, failed)
+	return completed, failed
+}
+
+// syncSession descarga las posiciones, vueltas y demás datos de una sesión,
+// los guarda con upsert por clave natural (las filas que OpenF1 ya no
+// publique no se borran) y recalcula su clasificación final.
+// Si alguna descarga falla no se toca lo que ya había y la sesión queda
+// como fallida para el próximo intento.
+func syncSession(s Session, job *syncJob) SyncState {
+	log.Printf("🔄 Procesando sesión %d: %s en %s", s.SessionKey, s.SessionName, s.CountryName)
+	job.update(s.SessionKey, func(p *sessionProgress) { p.Status = progressFetching })
+
+	now := time.Now()
+	state := SyncState{SessionKey: s.SessionKey, LastSyncedAt: &now}
+
+	// 1. Obtener posiciones
+	positions, err := source.Positions(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo posiciones para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("posiciones: %v", err))
+	}
+	log.Printf("✅ Obtenidas %d posiciones para sesión %d", len(positions), s.SessionKey)
+	job.update(s.SessionKey, func(p *sessionProgress) { p.PositionsFetched = len(positions) })
+
+	// 2. Obtener vueltas
+	laps, err := source.Laps(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo vueltas para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("vueltas: %v", err))
+	}
+	log.Printf("✅ Obtenidas %d vueltas para sesión %d", len(laps), s.SessionKey)
+	job.update(s.SessionKey, func(p *sessionProgress) { p.LapsFetched = len(laps) })
+
+	// 3. Obtener mensajes de dirección de carrera (abandonos,
+	// descalificaciones); una sesión sin mensajes es válida
+	messages, err := source.RaceControl(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo dirección de carrera para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("dirección de carrera: %v", err))
+	}
+	log.Printf("✅ Obtenidos %d mensajes de dirección de carrera para sesión %d", len(messages), s.SessionKey)
+
+	// 4. Obtener stints (neumáticos de cada tramo)
+	stints, err := source.Stints(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo stints para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("stints: %v", err))
+	}
+	log.Printf("✅ Obtenidos %d stints para sesión %d", len(stints), s.SessionKey)
+
+	// 5. Obtener paradas en boxes
+	pitStops, err := source.PitStops(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo paradas en boxes para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("paradas en boxes: %v", err))
+	}
+	log.Printf("✅ Obtenidas %d paradas en boxes para sesión %d", len(pitStops), s.SessionKey)
+
+	// 6. Obtener muestras de clima
+	weather, err := source.Weather(s.SessionKey)
+	if err != nil {
+		log.Printf("❌ Error obteniendo clima para sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, fmt.Errorf("clima: %v", err))
+	}
+	log.Printf("✅ Obtenidas %d muestras de clima para sesión %d", len(weather), s.SessionKey)
+
+	// 7. Obtener intervalos (diferencias con el líder y el de adelante);
+	// OpenF1 solo los publica en carreras y sprints
+	var intervals []Interval
+	if s.SessionName == sessionRace || s.SessionName == sessionSprint {
+		intervals, err = source.Intervals(s.SessionKey)
+		if err != nil {
+			log.Printf("❌ Error obteniendo intervalos para sesión %d: %v", s.SessionKey, err)
+			return failSession(state, job, fmt.Errorf("intervalos: %v", err))
+		}
+		log.Printf("✅ Obtenidos %d intervalos para sesión %d", len(intervals), s.SessionKey)
+	}
+
+	// Una sesión sin datos todavía (por ejemplo, recién terminada) se
+	// reintenta en la próxima sincronización
+	if len(positions) == 0 || len(laps) == 0 {
+		return failSession(state, job, fmt.Errorf("sin datos: %d posiciones, %d vueltas", len(positions), len(laps)))
+	}
+
+	for i := range positions {
+		positions[i].SessionKey = s.SessionKey
+	}
+	for i := range laps {
+		laps[i].SessionKey = s.SessionKey
+	}
+	for i := range messages {
+		messages[i].SessionKey = s.SessionKey
+	}
+	for i := range stints {
+		stints[i].SessionKey = s.SessionKey
+	}
+	for i := range pitStops {
+		pitStops[i].SessionKey = s.SessionKey
+	}
+	for i := range weather {
+		weather[i].SessionKey = s.SessionKey
+	}
+	for i := range intervals {
+		intervals[i].SessionKey = s.SessionKey
+	}
+
+	// 8. Upsert por clave natural en una sola transacción: reingerir la
+	// sesión actualiza las filas existentes en vez de duplicarlas
+	job.update(s.SessionKey, func(p *sessionProgress) { p.Status = progressInserting })
+	syncWriteMu.Lock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := upsertInBatches(tx, positions, len(positions), positionKey); err != nil {
+			return fmt.Errorf("insertando posiciones: %w", err)
+		}
+		if err := upsertInBatches(tx, laps, len(laps), lapKey); err != nil {
+			return fmt.Errorf("insertando vueltas: %w", err)
+		}
+		if err := upsertInBatches(tx, messages, len(messages), messageKey); err != nil {
+			return fmt.Errorf("insertando dirección de carrera: %w", err)
+		}
+		if err := upsertInBatches(tx, stints, len(stints), stintKey); err != nil {
+			return fmt.Errorf("insertando stints: %w", err)
+		}
+		if err := upsertInBatches(tx, pitStops, len(pitStops), pitStopKey); err != nil {
+			return fmt.Errorf("insertando paradas en boxes: %w", err)
+		}
+		if err := upsertInBatches(tx, weather, len(weather), weatherKey); err != nil {
+			return fmt.Errorf("insertando clima: %w", err)
+		}
+		if err := upsertInBatches(tx, intervals, len(intervals), intervalKey); err != nil {
+			return fmt.Errorf("insertando intervalos: %w", err)
+		}
+		if err := buildClassification(tx, s); err != nil {
+			return fmt.Errorf("calculando clasificación: %w", err)
+		}
+		return nil
+	})
+	syncWriteMu.Unlock()
+	if err != nil {
+		log.Printf("❌ Error guardando sesión %d: %v", s.SessionKey, err)
+		return failSession(state, job, err)
+	}
+
+	state.Status = syncComplete
+	state.Positions = len(positions)
+	state.Laps = len(laps)
+	log.Printf("✅ Sesión %d sincronizada: %d posiciones y %d vueltas", s.SessionKey, state.Positions, state.Laps)
+	job.update(s.SessionKey, func(p *sessionProgress) {
+		p.Status = progressComplete
+		p.RowsInserted = state.Positions + state.Laps + len(messages) + len(stints) + len(pitStops) + len(weather) + len(intervals)
+	})
+	return saveSyncState(state)
+}
+
+// upsertInBatches inserta rows (un slice) en lotes de 1000 y, si la clave
+// natural ya existe, actualiza la fila en lugar de duplicarla
+func upsertInBatches(tx *gorm.DB, rows interface{}, n int, key []clause.Column) error {
+	if n == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{Columns: key, UpdateAll: true}).CreateInBatches(rows, 1000).Error
+}
+
+// failSession marca la sesión como fallida en la base y en el job
+func failSession(state SyncState, job *syncJob, err error) SyncState {
+	state.Status = syncFailed
+	state.Error = err.Error()
+	job.update(state.SessionKey, func(p *sessionProgress) {
+		p.Status = progressFailed
+		p.Errors = append(p.Errors, err.Error())
+	})
+	return saveSyncState(state)
+}
+
+// saveSyncState guarda el estado de la sesión. Un fallo no pisa los
+// conteos del último intento exitoso, porque esos datos siguen en la base.
+func saveSyncState(state SyncState) SyncState {
+	syncWriteMu.Lock()
+	defer syncWriteMu.Unlock()
+
+	columns := []string{"status", "last_synced_at", "error"}
+	if state.Status == syncComplete {
+		columns = append(columns, "positions", "laps")
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_key"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&state).Error
+	if err != nil {
+		log.Printf("❌ Error guardando estado de sincronización de sesión %d: %v", state.SessionKey, err)
+	}
+	return state
+}
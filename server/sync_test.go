@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// useTestSource reemplaza la fuente de datos global durante el test
+func useTestSource(t *testing.T, s DataSource) {
+	t.Helper()
+	prev := source
+	source = s
+	t.Cleanup(func() { source = prev })
+}
+
+// countRows cuenta las filas de una sesión en la tabla de model
+func countRows(t *testing.T, model interface{}, sessionKey int) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Where("session_key = ?", sessionKey).Count(&n).Error; err != nil {
+		t.Fatalf("contando filas: %v", err)
+	}
+	return n
+}
+
+func TestSyncSession(t *testing.T) {
+	useTestDB(t)
+
+	const raceKey, qualiKey = 9574, 9570
+	race := Session{SessionKey: raceKey, SessionName: sessionRace, Year: 2024}
+	quali := Session{SessionKey: qualiKey, SessionName: sessionQualifying, Year: 2024}
+
+	m := newMemorySource()
+	m.sessions = []Session{race, quali}
+	for i, dn := range []uint{44, 81} {
+		for _, key := range []int{raceKey, qualiKey} {
+			m.positions = append(m.positions,
+				Position{SessionKey: key, DriverNumber: dn, Position: i + 1, Date: openF1Time(-60)},
+				Position{SessionKey: key, DriverNumber: dn, Position: i + 1, Date: openF1Time(400)},
+			)
+		}
+	}
+	m.laps = append(m.laps, raceLaps(raceKey, 44, 4, 0, 92, 90)...)
+	m.laps = append(m.laps, raceLaps(raceKey, 81, 4, 0.3, 93, 90.5)...)
+	m.laps = append(m.laps, raceLaps(qualiKey, 44, 3, 0, 80, 78)...)
+	m.laps = append(m.laps, raceLaps(qualiKey, 81, 3, 5, 85, 78.2)...)
+	m.raceControl = []RaceControl{
+		{SessionKey: raceKey, Date: openF1Time(-120), Message: "GREEN LIGHT - PIT EXIT OPEN", Flag: "GREEN", Scope: "Track"},
+	}
+	m.stints = []Stint{
+		{SessionKey: raceKey, DriverNumber: 44, StintNumber: 1, Compound: "MEDIUM", LapStart: 1, LapEnd: 4},
+		{SessionKey: raceKey, DriverNumber: 81, StintNumber: 1, Compound: "HARD", LapStart: 1, LapEnd: 4},
+	}
+	m.weather = []Weather{{SessionKey: raceKey, Date: openF1Time(0), AirTemperature: 24}}
+	m.intervals = []Interval{
+		{SessionKey: raceKey, DriverNumber: 44, Date: openF1Time(363)},
+		{SessionKey: raceKey, DriverNumber: 81, Date: openF1Time(364), GapToLeader: 2.761, GapToAhead: 2.761},
+		// OpenF1 no publica intervalos en clasificación: no se piden
+		{SessionKey: qualiKey, DriverNumber: 81, Date: openF1Time(200), GapToLeader: 0.2},
+	}
+	useTestSource(t, m)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		state := syncSession(race, nil)
+		if state.Status != syncComplete {
+			t.Fatalf("intento %d: estado %q (%s)", attempt, state.Status, state.Error)
+		}
+		if state.Positions != 4 || state.Laps != 8 {
+			t.Errorf("intento %d: %d posiciones y %d vueltas", attempt, state.Positions, state.Laps)
+		}
+
+		// Reingerir la sesión actualiza las filas en vez de duplicarlas
+		counts := []struct {
+			table string
+			model interface{}
+			want  int64
+		}{
+			{"positions", &Position{}, 4},
+			{"laps", &Lap{}, 8},
+			{"race_controls", &RaceControl{}, 1},
+			{"stints", &Stint{}, 2},
+			{"pit_stops", &PitStop{}, 0},
+			{"weathers", &Weather{}, 1},
+			{"intervals", &Interval{}, 2},
+			{"classifications", &Classification{}, 2},
+		}
+		for _, c := range counts {
+			if got := countRows(t, c.model, raceKey); got != c.want {
+				t.Errorf("intento %d: %d filas en %s, want %d", attempt, got, c.table, c.want)
+			}
+		}
+	}
+
+	var saved SyncState
+	if err := db.First(&saved, "session_key = ?", raceKey).Error; err != nil {
+		t.Fatalf("estado de sincronización: %v", err)
+	}
+	if saved.Status != syncComplete || saved.Positions != 4 || saved.Laps != 8 || saved.LastSyncedAt == nil {
+		t.Errorf("estado guardado: %+v", saved)
+	}
+
+	// La diferencia final sale de los intervalos
+	rows, err := sessionClassification(raceKey)
+	if err != nil {
+		t.Fatalf("sessionClassification: %v", err)
+	}
+	if len(rows) != 2 || rows[0].DriverNumber != 44 || rows[1].GapToWinner != 2.761 {
+		t.Errorf("clasificación: %+v", rows)
+	}
+
+	if state := syncSession(quali, nil); state.Status != syncComplete {
+		t.Fatalf("clasificación del sábado: estado %q (%s)", state.Status, state.Error)
+	}
+	if got := countRows(t, &Interval{}, qualiKey); got != 0 {
+		t.Errorf("se guardaron %d intervalos de la clasificación del sábado", got)
+	}
+}
+
+func TestSyncSessionWithoutData(t *testing.T) {
+	useTestDB(t)
+
+	const key = 9636
+	race := Session{SessionKey: key, SessionName: sessionRace, Year: 2024}
+
+	if err := db.Create(&race).Error; err != nil {
+		t.Fatalf("insertando sesión: %v", err)
+	}
+
+	m := newMemorySource()
+	m.sessions = []Session{race}
+	m.positions = []Position{{SessionKey: key, DriverNumber: 1, Position: 1, Date: openF1Time(0)}}
+	useTestSource(t, m)
+
+	// Recién terminada: hay posiciones pero OpenF1 todavía no publicó vueltas
+	state := syncSession(race, nil)
+	if state.Status != syncFailed || !strings.Contains(state.Error, "sin datos") {
+		t.Fatalf("estado %q (%s), want %q", state.Status, state.Error, syncFailed)
+	}
+	if got := countRows(t, &Position{}, key); got != 0 {
+		t.Errorf("se guardaron %d posiciones de una sesión fallida", got)
+	}
+
+	var saved SyncState
+	if err := db.First(&saved, "session_key = ?", key).Error; err != nil {
+		t.Fatalf("estado de sincronización: %v", err)
+	}
+	if saved.Status != syncFailed || saved.Error == "" {
+		t.Errorf("estado guardado: %+v", saved)
+	}
+
+	// Queda para el próximo intento
+	pending, err := pendingSessions()
+	if err != nil {
+		t.Fatalf("pendingSessions: %v", err)
+	}
+	if len(pending) != 1 || pending[0].SessionKey != key {
+		t.Errorf("pendientes: %+v", pending)
+	}
+}
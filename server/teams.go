@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// teamStanding acumula los puntos de un equipo en el campeonato de
+// constructores
+type teamStanding struct {
+	Team       string
+	TeamColour string
+	Points     int
+	Wins       int
+	Podiums    int
+	finishes   map[int]int
+}
+
+// sessionTeams devuelve el equipo con que corrió cada piloto en una sesión;
+// si falta la inscripción se usa el equipo actual del piloto
+func sessionTeams(sessionKey int, drivers map[uint]Driver) map[uint]DriverSession {
+	entries := sessionEntries(sessionKey)
+	for dn, d := range drivers {
+		if e, ok := entries[dn]; !ok || e.TeamName == "" {
+			entries[dn] = DriverSession{DriverNumber: dn, SessionKey: sessionKey, TeamName: d.TeamName, TeamColour: d.TeamColour}
+		}
+	}
+	return entries
+}
+
+// driversByNumber carga todos los pilotos indexados por número
+func driversByNumber() map[uint]Driver {
+	var drivers []Driver
+	db.Find(&drivers)
+
+	byNumber := make(map[uint]Driver, len(drivers))
+	for _, d := range drivers {
+		byNumber[d.DriverNumber] = d
+	}
+	return byNumber
+}
+
+// constructorTable es el campeonato de constructores en construcción
+type constructorTable struct {
+	byTeam map[string]*teamStanding
+}
+
+func newConstructorTable() *constructorTable {
+	return &constructorTable{byTeam: make(map[string]*teamStanding)}
+}
+
+// add suma los resultados de una sesión a los equipos con que corrió cada
+// piloto en esa sesión (los dos autos de cada equipo)
+func (t *constructorTable) add(s Session, results []sessionResult, teams map[uint]DriverSession) {
+	for _, r := range results {
+		e := teams[r.DriverNumber]
+		if e.TeamName == "" {
+			continue
+		}
+		ts, ok := t.byTeam[e.TeamName]
+		if !ok {
+			ts = &teamStanding{Team: e.TeamName, finishes: make(map[int]int)}
+			t.byTeam[e.TeamName] = ts
+		}
+		// El color se toma de la última sesión (puede cambiar entre temporadas)
+		ts.TeamColour = e.TeamColour
+		ts.Points += r.Points
+		if s.IsSprint {
+			continue
+		}
+		ts.finishes[r.Position]++
+		if r.Position == 1 {
+			ts.Wins++
+		}
+		if r.Position <= 3 {
+			ts.Podiums++
+		}
+	}
+}
+
+// sorted devuelve la clasificación con el mismo desempate que la de
+// pilotos: puntos y luego countback
+func (t *constructorTable) sorted() []*teamStanding {
+	out := make([]*teamStanding, 0, len(t.byTeam))
+	maxPosition := 0
+	for _, ts := range t.byTeam {
+		out = append(out, ts)
+		for pos := range ts.finishes {
+			if pos > maxPosition {
+				maxPosition = pos
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		for pos := 1; pos <= maxPosition; pos++ {
+			if a.finishes[pos] != b.finishes[pos] {
+				return a.finishes[pos] > b.finishes[pos]
+			}
+		}
+		return a.Team < b.Team
+	})
+	return out
+}
+
+// constructorStandings calcula el campeonato de constructores de una
+// temporada, carrera por carrera
+func constructorStandings(year int) ([]*teamStanding, int, error) {
+	sessions, err := scoredSessions(year)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	drivers := driversByNumber()
+	table := newConstructorTable()
+	rounds := 0
+	for _, s := range sessions {
+		results, err := scoreSession(s)
+		if err != nil {
+			log.Printf("Error puntuando sesión %d: %v", s.SessionKey, err)
+			continue
+		}
+		if !s.IsSprint && len(results) > 0 {
+			rounds++
+		}
+		table.add(s, results, sessionTeams(s.SessionKey, drivers))
+	}
+	return table.sorted(), rounds, nil
+}
+
+// GET /api/temporada/:year/constructores
+func getConstructorStandings(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+		return
+	}
+
+	standings, rounds, err := constructorStandings(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la clasificación"})
+		return
+	}
+	if rounds == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(standings))
+	for i, t := range standings {
+		out = append(out, gin.H{
+			"position":    i + 1,
+			"team":        t.Team,
+			"team_colour": t.TeamColour,
+			"points":      t.Points,
+			"wins":        t.Wins,
+			"podiums":     t.Podiums,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":    year,
+		"rounds":    rounds,
+		"standings": out,
+	})
+}
+
+// GET /api/equipo/:name?year=2024
+func getTeamDetail(c *gin.Context) {
+	name := c.Param("name")
+
+	year := defaultSeason()
+	if y := c.Query("year"); y != "" {
+		var err error
+		year, err = strconv.Atoi(y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+			return
+		}
+	}
+
+	sessions, err := scoredSessions(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las sesiones"})
+		return
+	}
+
+	drivers := driversByNumber()
+	team, teamColour := "", ""
+	totalPoints := 0
+	fastestLaps := 0
+	bestFinish := 0
+	races := []gin.H{}
+	driverRaces := make(map[uint]int)
+	driverPoints := make(map[uint]int)
+
+	for _, s := range sessions {
+		teams := sessionTeams(s.SessionKey, drivers)
+		results, err := scoreSession(s)
+		if err != nil {
+			log.Printf("Error puntuando sesión %d: %v", s.SessionKey, err)
+			continue
+		}
+
+		points := 0
+		sessionBest := 0
+		fastestLap := false
+		entries := []gin.H{}
+		for _, r := range results {
+			e := teams[r.DriverNumber]
+			if !strings.EqualFold(e.TeamName, name) {
+				continue
+			}
+			team, teamColour = e.TeamName, e.TeamColour
+
+			points += r.Points
+			if !s.IsSprint {
+				driverRaces[r.DriverNumber]++
+			}
+			driverPoints[r.DriverNumber] += r.Points
+			if sessionBest == 0 || r.Position < sessionBest {
+				sessionBest = r.Position
+			}
+			if r.FastestLap {
+				fastestLap = true
+			}
+
+			d := drivers[r.DriverNumber]
+			entries = append(entries, gin.H{
+				"driver_number": r.DriverNumber,
+				"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+				"position":      r.Position,
+				"points":        r.Points,
+				"fastest_lap":   r.FastestLap,
+			})
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		totalPoints += points
+		if fastestLap {
+			fastestLaps++
+		}
+		// Las mejores llegadas cuentan solo carreras, no sprints
+		if !s.IsSprint && (bestFinish == 0 || sessionBest < bestFinish) {
+			bestFinish = sessionBest
+		}
+
+		raceName := fmt.Sprintf("GP de %s", s.CountryName)
+		if s.IsSprint {
+			raceName = fmt.Sprintf("Sprint de %s", s.CountryName)
+		}
+		races = append(races, gin.H{
+			"session_key":        s.SessionKey,
+			"race":               raceName,
+			"circuit_short_name": s.CircuitShortName,
+			"is_sprint":          s.IsSprint,
+			"points":             points,
+			"best_finish":        sessionBest,
+			"fastest_lap":        fastestLap,
+			"drivers":            entries,
+		})
+	}
+
+	if team == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Equipo no encontrado"})
+		return
+	}
+
+	teamDrivers := []gin.H{}
+	for dn, points := range driverPoints {
+		d := drivers[dn]
+		teamDrivers = append(teamDrivers, gin.H{
+			"driver_number": dn,
+			"driver":        fmt.Sprintf("%s %s", d.FirstName, d.LastName),
+			"country":       d.CountryCode,
+			"races":         driverRaces[dn],
+			"points":        points,
+		})
+	}
+	sort.Slice(teamDrivers, func(i, j int) bool {
+		if teamDrivers[i]["points"].(int) != teamDrivers[j]["points"].(int) {
+			return teamDrivers[i]["points"].(int) > teamDrivers[j]["points"].(int)
+		}
+		return teamDrivers[i]["driver_number"].(uint) < teamDrivers[j]["driver_number"].(uint)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"team":         team,
+		"team_colour":  teamColour,
+		"season":       year,
+		"points":       totalPoints,
+		"best_finish":  bestFinish,
+		"fastest_laps": fastestLaps,
+		"drivers":      teamDrivers,
+		"race_results": races,
+	})
+}
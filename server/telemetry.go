@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CarData es una muestra de telemetría de OpenF1 (car_data). OpenF1 publica
+// unas cuatro por segundo; se guardan reducidas a cfg.TelemetryRate.
+type CarData struct {
+	SessionKey   int    `json:"session_key" gorm:"uniqueIndex:idx_car_data_natural,priority:1"`
+	DriverNumber uint   `json:"driver_number" gorm:"uniqueIndex:idx_car_data_natural,priority:2"`
+	Date         string `json:"date" gorm:"uniqueIndex:idx_car_data_natural,priority:3"`
+	Speed        int    `json:"speed"`
+	RPM          int    `json:"rpm"`
+	Gear         int    `json:"n_gear"`
+	Throttle     int    `json:"throttle"`
+	Brake        int    `json:"brake"`
+	DRS          int    `json:"drs"`
+}
+
+// downsampleCarData se queda con una muestra cada 1/rate segundos
+// (samples de un piloto, en orden cronológico)
+func downsampleCarData(samples []CarData, rate float64) []CarData {
+	if rate <= 0 {
+		return samples
+	}
+	step := time.Duration(float64(time.Second) / rate)
+
+	out := make([]CarData, 0, len(samples))
+	var next time.Time
+	for _, s := range samples {
+		t, ok := parseOpenF1Time(s.Date)
+		if !ok {
+			continue
+		}
+		if !next.IsZero() && t.Before(next) {
+			continue
+		}
+		out = append(out, s)
+		next = t.Add(step)
+	}
+	return out
+}
+
+// telemetryDrivers devuelve los pilotos de una sesión cuya telemetría se
+// ingiere: los configurados o, si no hay, todos los que tienen posiciones
+func telemetryDrivers(sessionKey int) []uint {
+	if len(cfg.TelemetryDrivers) > 0 {
+		drivers := make([]uint, 0, len(cfg.TelemetryDrivers))
+		for _, dn := range cfg.TelemetryDrivers {
+			drivers = append(drivers, uint(dn))
+		}
+		return drivers
+	}
+	var drivers []uint
+	db.Model(&Position{}).Where("session_key = ?", sessionKey).Distinct().Order("driver_number ASC").Pluck("driver_number", &drivers)
+	return drivers
+}
+
+// syncTelemetry descarga la telemetría de las sesiones configuradas en
+// cfg.TelemetrySessions ya sincronizadas. Sin force solo baja los pilotos
+// que todavía no tienen muestras. Un error en un piloto no frena al resto
+// ni marca la sesión como fallida. Devuelve cuántas muestras se guardaron.
+func syncTelemetry(force bool) int {
+	total := 0
+	for _, sessionKey := range cfg.TelemetrySessions {
+		var state SyncState
+		if err := db.First(&state, "session_key = ?", sessionKey).Error; err != nil || state.Status != syncComplete {
+			log.Printf("⚠️ Telemetría: la sesión %d todavía no está sincronizada", sessionKey)
+			continue
+		}
+
+		for _, dn := range telemetryDrivers(sessionKey) {
+			if !force {
+				var stored int64
+				db.Model(&CarData{}).Where("session_key = ? AND driver_number = ?", sessionKey, dn).Count(&stored)
+				if stored > 0 {
+					continue
+				}
+			}
+
+			samples, err := source.CarData(sessionKey, dn)
+			if err != nil {
+				log.Printf("❌ Error obteniendo telemetría del piloto %d en sesión %d: %v", dn, sessionKey, err)
+				continue
+			}
+			sort.Slice(samples, func(i, j int) bool { return samples[i].Date < samples[j].Date })
+			for i := range samples {
+				samples[i].SessionKey = sessionKey
+				samples[i].DriverNumber = dn
+			}
+			kept := downsampleCarData(samples, cfg.TelemetryRate)
+
+			syncWriteMu.Lock()
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Where("session_key = ? AND driver_number = ?", sessionKey, dn).Delete(&CarData{}).Error; err != nil {
+					return err
+				}
+				if len(kept) == 0 {
+					return nil
+				}
+				return tx.CreateInBatches(kept, 1000).Error
+			})
+			syncWriteMu.Unlock()
+			if err != nil {
+				log.Printf("❌ Error guardando telemetría del piloto %d en sesión %d: %v", dn, sessionKey, err)
+				continue
+			}
+			log.Printf("✅ Telemetría del piloto %d en sesión %d: %d de %d muestras", dn, sessionKey, len(kept), len(samples))
+			total += len(kept)
+		}
+	}
+	return total
+}
+
+// GET /api/carrera/telemetria/:id/:driver/:lap
+func getLapTelemetry(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+	driverNumber, err := strconv.Atoi(c.Param("driver"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Número de piloto inválido"})
+		return
+	}
+	lapNumber, err := strconv.Atoi(c.Param("lap"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Número de vuelta inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+	var driver Driver
+	if err := db.First(&driver, "driver_number = ?", driverNumber).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Piloto no encontrado"})
+		return
+	}
+
+	var lap Lap
+	if err := db.First(&lap, "session_key = ? AND driver_number = ? AND lap_number = ?", sessionKey, driverNumber, lapNumber).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vuelta no encontrada"})
+		return
+	}
+	start, ok := parseOpenF1Time(lap.DateStart)
+	if !ok || lap.LapDuration <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "La vuelta no tiene hora de inicio o duración"})
+		return
+	}
+	end := start.Add(time.Duration(lap.LapDuration * float64(time.Second)))
+
+	// Solo las muestras de la vuelta: desde su inicio hasta el de la
+	// siguiente (o hasta el final según su duración, si fue la última). Las
+	// fechas de OpenF1 están todas en UTC con el mismo formato, así que se
+	// comparan como texto y se usa el índice de car_data.
+	until := end.UTC().Format("2006-01-02T15:04:05.000000") + "+00:00"
+	var next Lap
+	err = db.Where("session_key = ? AND driver_number = ? AND lap_number = ? AND date_start <> ''", sessionKey, driverNumber, lapNumber+1).
+		Limit(1).Find(&next).Error
+	if err == nil && next.DateStart != "" {
+		until = next.DateStart
+	}
+
+	var samples []CarData
+	err = db.Where("session_key = ? AND driver_number = ? AND date >= ? AND date < ?", sessionKey, driverNumber, lap.DateStart, until).
+		Order("date ASC").Find(&samples).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la telemetría"})
+		return
+	}
+
+	// Cada muestra lleva el tiempo desde el inicio de la vuelta y la
+	// distancia recorrida (integrando la velocidad), para superponer las
+	// trazas de dos pilotos o dos vueltas
+	trace := make([]gin.H, 0)
+	distance, maxSpeed := 0.0, 0
+	var prev *CarData
+	var prevAt time.Time
+	for i := range samples {
+		s := samples[i]
+		t, ok := parseOpenF1Time(s.Date)
+		if !ok || t.Before(start) || !t.Before(end) {
+			continue
+		}
+		if prev != nil {
+			// km/h a m/s con la velocidad media del tramo
+			distance += float64(prev.Speed+s.Speed) / 2 / 3.6 * t.Sub(prevAt).Seconds()
+		}
+		if s.Speed > maxSpeed {
+			maxSpeed = s.Speed
+		}
+		trace = append(trace, gin.H{
+			"date":     s.Date,
+			"time":     roundMillis(t.Sub(start).Seconds()),
+			"distance": roundTenths(distance),
+			"speed":    s.Speed,
+			"rpm":      s.RPM,
+			"gear":     s.Gear,
+			"throttle": s.Throttle,
+			"brake":    s.Brake,
+			"drs":      s.DRS,
+		})
+		prev, prevAt = &samples[i], t
+	}
+
+	team, teamColour := teamOf(sessionEntries(sessionKey), driver)
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":       session.SessionKey,
+		"driver_number": driver.DriverNumber,
+		"driver":        fmt.Sprintf("%s %s", driver.FirstName, driver.LastName),
+		"team":          team,
+		"team_colour":   teamColour,
+		"lap_number":    lap.LapNumber,
+		"lap_duration":  lap.LapDuration,
+		"st_speed":      lap.StSpeed,
+		"max_speed":     maxSpeed,
+		"samples":       trace,
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestDownsampleCarData(t *testing.T) {
+	// Unas cuatro muestras por segundo, como las publica OpenF1
+	samples := []CarData{
+		{Date: openF1Time(0), Speed: 100},
+		{Date: openF1Time(0.27), Speed: 101},
+		{Date: openF1Time(0.5), Speed: 102},
+		{Date: openF1Time(0.77), Speed: 103},
+		{Date: "sin fecha", Speed: 999},
+		{Date: openF1Time(1.0), Speed: 104},
+		{Date: openF1Time(1.23), Speed: 105},
+		{Date: openF1Time(1.6), Speed: 106},
+	}
+
+	tests := []struct {
+		name string
+		rate float64
+		want []int
+	}{
+		{name: "dos por segundo", rate: 2, want: []int{100, 102, 104, 106}},
+		{name: "una por segundo", rate: 1, want: []int{100, 104}},
+		{name: "más que las publicadas", rate: 10, want: []int{100, 101, 102, 103, 104, 105, 106}},
+		{name: "sin reducir", rate: 0, want: []int{100, 101, 102, 103, 999, 104, 105, 106}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := downsampleCarData(samples, tt.rate)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d muestras, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, speed := range tt.want {
+				if got[i].Speed != speed {
+					t.Errorf("muestra %d: speed %d, want %d", i, got[i].Speed, speed)
+				}
+			}
+		})
+	}
+
+	if got := downsampleCarData(nil, 2); len(got) != 0 {
+		t.Errorf("sin muestras: got %d", len(got))
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Weather es una muestra de clima de OpenF1 (aproximadamente una por
+// minuto). Rainfall es 1 si llovía al tomar la muestra.
+type Weather struct {
+	SessionKey       int     `json:"session_key" gorm:"uniqueIndex:idx_weathers_natural,priority:1"`
+	Date             string  `json:"date" gorm:"uniqueIndex:idx_weathers_natural,priority:2"`
+	AirTemperature   float64 `json:"air_temperature"`
+	TrackTemperature float64 `json:"track_temperature"`
+	Humidity         float64 `json:"humidity"`
+	Pressure         float64 `json:"pressure"`
+	Rainfall         int     `json:"rainfall"`
+	WindDirection    int     `json:"wind_direction"`
+	WindSpeed        float64 `json:"wind_speed"`
+}
+
+// weatherSummary resume el clima de una sesión; nil si no hay muestras
+func weatherSummary(sessionKey int) gin.H {
+	var summary struct {
+		Samples     int
+		MinTrack    float64
+		MaxTrack    float64
+		AvgTrack    float64
+		AvgAir      float64
+		AvgHumidity float64
+		MaxWind     float64
+		Rained      int
+	}
+	db.Model(&Weather{}).
+		Select(`COUNT(*) AS samples,
+                MIN(track_temperature) AS min_track,
+                MAX(track_temperature) AS max_track,
+                AVG(track_temperature) AS avg_track,
+                AVG(air_temperature) AS avg_air,
+                AVG(humidity) AS avg_humidity,
+                MAX(wind_speed) AS max_wind,
+                MAX(rainfall) AS rained`).
+		Where("session_key = ?", sessionKey).
+		Scan(&summary)
+
+	if summary.Samples == 0 {
+		return nil
+	}
+	return gin.H{
+		"samples":               summary.Samples,
+		"track_temperature_min": summary.MinTrack,
+		"track_temperature_max": summary.MaxTrack,
+		"track_temperature_avg": roundTenths(summary.AvgTrack),
+		"air_temperature_avg":   roundTenths(summary.AvgAir),
+		"humidity_avg":          roundTenths(summary.AvgHumidity),
+		"wind_speed_max":        summary.MaxWind,
+		"rained":                summary.Rained > 0,
+	}
+}
+
+// roundTenths redondea a un decimal, la precisión de los sensores de clima
+func roundTenths(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// leaderLap es el inicio de una vuelta del líder: el número de vuelta de
+// OpenF1 y la hora en que el primer auto la empezó
+type leaderLap struct {
+	LapNumber int
+	Start     time.Time
+}
+
+// leaderLapStarts devuelve, en orden, el inicio de cada vuelta de la sesión.
+// Las vueltas sin hora de inicio se omiten, así que los números pueden
+// saltearse.
+func leaderLapStarts(sessionKey int) []leaderLap {
+	type lapStart struct {
+		LapNumber int
+		DateStart string
+	}
+	var starts []lapStart
+	db.Model(&Lap{}).
+		Select("lap_number, MIN(date_start) AS date_start").
+		Where("session_key = ? AND date_start <> ''", sessionKey).
+		Group("lap_number").
+		Order("lap_number ASC").
+		Scan(&starts)
+
+	out := make([]leaderLap, 0, len(starts))
+	for _, s := range starts {
+		if t, ok := parseOpenF1Time(s.DateStart); ok {
+			out = append(out, leaderLap{LapNumber: s.LapNumber, Start: t})
+		}
+	}
+	return out
+}
+
+// GET /api/carrera/clima/:id
+func getRaceWeather(c *gin.Context) {
+	sessionKey, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de carrera inválido"})
+		return
+	}
+
+	var session Session
+	if err := db.First(&session, "session_key = ?", sessionKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Carrera no encontrada"})
+		return
+	}
+
+	var samples []Weather
+	if err := db.Where("session_key = ?", sessionKey).Order("date ASC").Find(&samples).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el clima"})
+		return
+	}
+
+	// Cada muestra lleva la vuelta en curso del líder, para cruzarla con los
+	// tiempos de vuelta (0 antes de la largada)
+	lapStarts := leaderLapStarts(sessionKey)
+
+	series := make([]gin.H, 0, len(samples))
+	for _, w := range samples {
+		lap := 0
+		if t, ok := parseOpenF1Time(w.Date); ok {
+			lap = lapAt(lapStarts, t)
+		}
+		series = append(series, gin.H{
+			"date":              w.Date,
+			"lap_number":        lap,
+			"air_temperature":   w.AirTemperature,
+			"track_temperature": w.TrackTemperature,
+			"humidity":          w.Humidity,
+			"pressure":          w.Pressure,
+			"rainfall":          w.Rainfall > 0,
+			"wind_direction":    w.WindDirection,
+			"wind_speed":        w.WindSpeed,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"race_id":            session.SessionKey,
+		"country_name":       session.CountryName,
+		"circuit_short_name": session.CircuitShortName,
+		"summary":            weatherSummary(sessionKey),
+		"samples":            series,
+	})
+}